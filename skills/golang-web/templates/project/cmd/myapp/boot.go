@@ -0,0 +1,143 @@
+// cmd/myapp/boot.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/pkg/database"
+	"github.com/yourname/myapp/pkg/retry"
+	"github.com/yourname/myapp/pkg/storage"
+)
+
+// Exit codes. Each boot phase that can fail has its own, so supervisors
+// and deploy tooling can tell why the process did not come up.
+const (
+	exitError    = 1 // runtime or subcommand failure
+	exitUsage    = 2 // unknown subcommand
+	exitConfig   = 3
+	exitTracing  = 4
+	exitDatabase = 5
+	exitListen   = 6
+)
+
+// bootPhase runs one startup step and logs how long it took. If fn fails,
+// the failure is logged with the phase name and the process exits with
+// code.
+func bootPhase(name string, code int, fn func() error) {
+	start := time.Now()
+	if err := fn(); err != nil {
+		bootFailed(name, code, err, time.Since(start))
+	}
+	logBootPhase(name, time.Since(start))
+}
+
+func logBootPhase(name string, took time.Duration) {
+	slog.Info("boot phase complete", "phase", name, "duration", took)
+}
+
+func bootFailed(name string, code int, err error, took time.Duration) {
+	slog.Error("boot phase failed", "phase", name, "duration", took, "exit_code", code, "error", err)
+	os.Exit(code)
+}
+
+// connectDatabase opens and pings the database, retrying with backoff for
+// up to cfg.StartupTimeout so the app waits out a database that is still
+// starting, e.g. when both come up together in a cluster, instead of
+// crash-looping. With no timeout it tries once.
+func connectDatabase(cfg configs.DatabaseConfig, opts ...database.Option) (*database.Database, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	defer cancel()
+
+	policy := retry.Policy{
+		MaxAttempts:    math.MaxInt,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+	if cfg.StartupTimeout <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	var db *database.Database
+	attempt := 0
+	err := retry.Do(ctx, policy, func() error {
+		attempt++
+		var err error
+		db, err = openDatabase(cfg, opts)
+		if err != nil && policy.MaxAttempts > 1 {
+			slog.Warn("database not reachable", "attempt", attempt, "error", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attempt > 1 {
+		slog.Info("database reachable", "attempts", attempt)
+	}
+	return db, nil
+}
+
+// openDatabase makes one attempt for connectDatabase
+func openDatabase(cfg configs.DatabaseConfig, opts []database.Option) (*database.Database, error) {
+	db, err := database.New(database.FromConfig(cfg), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// newS3Storage creates the S3 store. Credentials and, unless configured,
+// the region come from the standard AWS sources: environment variables,
+// shared config files or the instance role.
+func newS3Storage(cfg configs.S3StorageConfig) (*storage.S3, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage.s3.bucket is required")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.PathStyle
+	})
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		switch {
+		case cfg.Endpoint != "" && cfg.PathStyle:
+			publicURL = cfg.Endpoint + "/" + cfg.Bucket
+		case cfg.Endpoint != "":
+			return nil, errors.New("storage.s3.public_url is required with a custom endpoint unless path_style is set")
+		default:
+			publicURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", cfg.Bucket, awsCfg.Region)
+		}
+	}
+	return storage.NewS3(client, cfg.Bucket, cfg.Prefix, publicURL), nil
+}
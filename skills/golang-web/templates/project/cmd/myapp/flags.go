@@ -0,0 +1,58 @@
+// cmd/myapp/flags.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yourname/myapp/configs"
+)
+
+// cliFlags are the command-line flags. Those that are set override the
+// config file and environment.
+type cliFlags struct {
+	config  string
+	port    *int // nil unless given
+	migrate bool
+	seed    bool
+}
+
+// parseFlags parses the flags before the subcommand, if any, and returns
+// them with the remaining arguments. Invalid flags exit with exitUsage.
+func parseFlags() (cliFlags, []string) {
+	var f cliFlags
+	var port int
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&f.config, "config", "", "config file to read instead of the one chosen by APP_ENV")
+	fs.IntVar(&port, "port", 0, "port to listen on, overriding server.port")
+	fs.BoolVar(&f.migrate, "migrate", false, "apply pending migrations before serving, as database.schema_check: migrate does")
+	fs.BoolVar(&f.seed, "seed", false, "insert demo users before serving, as the seed command does")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] [migrate up|down|version | seed [-count N] | backup PATH | restore PATH]\n\nFlags:\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:]) // exits on error
+
+	fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "port" {
+			f.port = &port
+		}
+	})
+	return f, fs.Args()
+}
+
+// loadOptions returns the configs.Load options for the flags that were set
+func (f cliFlags) loadOptions() []configs.LoadOption {
+	var opts []configs.LoadOption
+	if f.config != "" {
+		opts = append(opts, configs.WithFile(f.config))
+	}
+	if f.port != nil {
+		opts = append(opts, configs.WithOverride("server.port", *f.port))
+	}
+	if f.migrate {
+		opts = append(opts, configs.WithOverride("database.schema_check", configs.SchemaCheckMigrate))
+	}
+	return opts
+}
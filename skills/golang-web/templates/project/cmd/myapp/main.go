@@ -2,57 +2,426 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
+	"sync/atomic"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/yourname/myapp/configs"
 	"github.com/yourname/myapp/internal/handlers"
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/outbox"
 	"github.com/yourname/myapp/internal/repositories"
 	"github.com/yourname/myapp/internal/router"
 	"github.com/yourname/myapp/internal/services"
+	"github.com/yourname/myapp/pkg/alert"
+	"github.com/yourname/myapp/pkg/cache"
 	"github.com/yourname/myapp/pkg/database"
+	"github.com/yourname/myapp/pkg/health"
+	"github.com/yourname/myapp/pkg/llm"
+	"github.com/yourname/myapp/pkg/lockout"
+	"github.com/yourname/myapp/pkg/logger"
+	"github.com/yourname/myapp/pkg/middleware"
+	"github.com/yourname/myapp/pkg/response"
+	"github.com/yourname/myapp/pkg/retry"
 	"github.com/yourname/myapp/pkg/server"
+	"github.com/yourname/myapp/pkg/storage"
+	"github.com/yourname/myapp/pkg/tracing"
+	"github.com/yourname/myapp/pkg/worker"
+	"github.com/yourname/myapp/pkg/ws"
+	"golang.org/x/time/rate"
 )
 
+// @title			MyApp API
+// @version		1.0
+// @description	User management API.
+// @BasePath		/api/v1
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Bearer JWT, e.g. "Bearer eyJ..."
 func main() {
+	bootStart := time.Now()
+	flags, args := parseFlags()
+
+	// Load configuration. The logger is built from it, so the phase is
+	// logged once the logger exists.
+	cfg, err := configs.Load(flags.loadOptions()...)
+	if err != nil {
+		bootFailed("config", exitConfig, err, time.Since(bootStart))
+	}
+
 	// Initialize logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
+	level := new(slog.LevelVar)
+	slog.SetDefault(logger.New(cfg.Log, level))
+	logBootPhase("config", time.Since(bootStart))
 
-	// Load configuration
-	cfg := configs.Load()
+	// Refuse half a TLS setup before connecting to anything, rather than
+	// serving plain HTTP
+	bootPhase("tls", exitConfig, cfg.Server.TLS.Validate)
 
-	// Initialize database
-	db, err := database.New(cfg.Database)
-	if err != nil {
-		slog.Error("failed to connect to database", "error", err)
-		os.Exit(1)
+	// Maintenance mode starts as configured; admins flip it via the API
+	maintenance := new(atomic.Bool)
+	maintenance.Store(cfg.Maintenance.Enabled)
+	maintenanceConfigured := cfg.Maintenance.Enabled
+	levelConfigured := cfg.Log.Level
+	rateLimitConfigured := cfg.RateLimit
+
+	// setRateLimit adjusts the rate limiter, if enabled
+	var setRateLimit func(rate.Limit, int)
+
+	// applyConfig applies the hot-reloadable settings of a reloaded config:
+	// log level, maintenance mode and rate limits. Admins can also change the
+	// first two via the API. Registered once the app is built, see below.
+	applyConfig := func(c *configs.Config) {
+		// Follow edits only, so unrelated reloads keep a state set via the API
+		if c.Maintenance.Enabled != maintenanceConfigured {
+			maintenanceConfigured = c.Maintenance.Enabled
+			maintenance.Store(c.Maintenance.Enabled)
+			slog.Info("maintenance mode changed", "enabled", c.Maintenance.Enabled, "by", "config")
+		}
+
+		if c.Log.Level != levelConfigured {
+			levelConfigured = c.Log.Level
+			if lvl, ok := logger.ParseLevel(c.Log.Level); ok {
+				level.Set(lvl)
+				slog.Warn("log level changed", "to", logger.LevelName(lvl), "by", "config")
+			} else {
+				slog.Warn("unknown log level, keeping current", "level", c.Log.Level)
+			}
+		}
+
+		rl := c.RateLimit
+		if setRateLimit != nil && (rl.RPS != rateLimitConfigured.RPS || rl.Burst != rateLimitConfigured.Burst) {
+			rateLimitConfigured.RPS, rateLimitConfigured.Burst = rl.RPS, rl.Burst
+			if err := rl.Validate(); err != nil {
+				slog.Warn("invalid rate limit, keeping current", "error", err)
+			} else {
+				setRateLimit(rate.Limit(rl.RPS), rl.Burst)
+				slog.Info("rate limit changed", "rps", rl.RPS, "burst", rl.Burst)
+			}
+		}
+	}
+
+	// Initialize tracing
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.Enabled {
+		bootPhase("tracing", exitTracing, func() (err error) {
+			shutdownTracing, err = tracing.Init(context.Background(), cfg.Tracing)
+			return err
+		})
+	}
+
+	// Report panics and server errors beyond the log
+	var sentry *alert.Sentry
+	if cfg.Alert.SentryDSN != "" {
+		bootPhase("alert", exitConfig, func() (err error) {
+			sentry, err = alert.NewSentry(cfg.Alert.SentryDSN, cfg.Alert.Environment)
+			if err == nil {
+				alert.SetReporter(sentry)
+			}
+			return err
+		})
 	}
-	defer db.Close()
+
+	// Initialize the primary database. Further entries under databases,
+	// e.g. databases.analytics, are opened the same way with their own name.
+	dbCfg, _ := cfg.DatabaseNamed(configs.PrimaryDatabase)
+
+	// Backup and restore work on sqlite files only; say so without waiting
+	// for a server database to connect
+	if len(args) > 0 && (args[0] == "backup" || args[0] == "restore") {
+		if err := requireSQLite(dbCfg.Driver, args[0]); err != nil {
+			slog.Error(args[0]+" failed", "error", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	var db *database.Database
+	bootPhase("database", exitDatabase, func() error {
+		db, err = connectDatabase(dbCfg,
+			database.WithReconnect(dbCfg.ReconnectInterval),
+			database.WithQueryLog(slog.Default(), dbCfg.SlowQueryThreshold),
+			database.WithTxRetry(retry.FromConfig(dbCfg.TxRetry, database.IsSerializationFailure)),
+			database.WithTenantScope(models.TenantFrom),
+		)
+		return err
+	})
+
+	bootPhase("id_strategy", exitConfig, func() error {
+		// Database-side IDs rely on the Postgres column default
+		if dbCfg.IDStrategy == models.IDStrategyDatabase && dbCfg.Driver != "postgres" {
+			return fmt.Errorf("id strategy database requires postgres, got %s", dbCfg.Driver)
+		}
+		return models.SetIDStrategy(dbCfg.IDStrategy)
+	})
+
+	bootPhase("encryption_keys", exitConfig, func() error {
+		return database.SetEncryptionKeys(dbCfg.EncryptionKeys)
+	})
+
+	bootPhase("field_naming", exitConfig, func() error {
+		return response.SetFieldNaming(cfg.Response.FieldNaming)
+	})
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db.DB())
+	outboxRepo := repositories.NewOutboxRepository(db.DB())
+
+	// Subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(db, dbCfg.Driver, args[1:]); err != nil {
+				slog.Error("migration failed", "error", err)
+				os.Exit(exitError)
+			}
+		case "seed":
+			if err := runSeed(context.Background(), userRepo, cfg.Server.Mode, args[1:]); err != nil {
+				slog.Error("seed failed", "error", err)
+				os.Exit(exitError)
+			}
+		case "backup":
+			if err := runBackup(db, args[1:]); err != nil {
+				slog.Error("backup failed", "error", err)
+				os.Exit(exitError)
+			}
+		case "restore":
+			if err := runRestore(db, args[1:]); err != nil {
+				slog.Error("restore failed", "error", err)
+				os.Exit(exitError)
+			}
+		default:
+			slog.Error("unknown command", "command", args[0])
+			os.Exit(exitUsage)
+		}
+		return
+	}
+
+	// Refuse to run against a schema older than the code, unless configured
+	// otherwise
+	bootPhase("schema", exitDatabase, func() error {
+		return checkSchema(dbCfg)
+	})
+
+	if flags.seed {
+		bootPhase("seed", exitError, func() error {
+			return runSeed(context.Background(), userRepo, cfg.Server.Mode, nil)
+		})
+	}
+
+	// Redis is shared by the features configured to use it, and created
+	// on first use. It is not pinged: those features keep working without it.
+	var rdb *redis.Client
+	redisClient := func() *redis.Client {
+		if rdb == nil {
+			rdb = redis.NewClient(&redis.Options{
+				Addr:         cfg.Redis.Addr,
+				Password:     cfg.Redis.Password,
+				DB:           cfg.Redis.DB,
+				DialTimeout:  cfg.Redis.DialTimeout,
+				ReadTimeout:  cfg.Redis.ReadTimeout,
+				WriteTimeout: cfg.Redis.WriteTimeout,
+			})
+		}
+		return rdb
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, outboxRepo, db)
+	if cfg.Cache.Enabled {
+		bootPhase("cache", exitConfig, func() error {
+			var c cache.Cache
+			switch cfg.Cache.Backend {
+			case configs.CacheMemory:
+				c = cache.NewLRU(cfg.Cache.Size)
+			case configs.CacheRedis:
+				c = cache.NewRedis(redisClient(), cfg.Cache.KeyPrefix)
+			default:
+				return fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+			}
+			userService = services.NewCachedUserService(userService, c, cfg.Cache.TTL)
+			return nil
+		})
+	}
+
+	// Publish domain events; swap LogPublisher for a broker client
+	dispatcher := outbox.NewDispatcher(outboxRepo, outbox.LogPublisher{Logger: slog.Default()},
+		outbox.WithPollInterval(cfg.Outbox.PollInterval),
+		outbox.WithBatchSize(cfg.Outbox.BatchSize),
+		outbox.WithBackoff(cfg.Outbox.InitialBackoff, cfg.Outbox.MaxBackoff),
+	)
+	if cfg.Outbox.Enabled {
+		dispatcher.Start()
+	}
+
+	// Background jobs, drained on shutdown
+	jobs := worker.New(
+		worker.WithWorkers(cfg.Worker.Workers),
+		worker.WithQueueSize(cfg.Worker.QueueSize),
+	)
+
+	// Uploaded files, kept on local disk or in an S3 bucket
+	var store storage.Storage
+	bootPhase("storage", exitConfig, func() (err error) {
+		switch cfg.Storage.Backend {
+		case configs.StorageLocal:
+			store, err = storage.NewLocal(cfg.Storage.Local.Dir, cfg.Storage.Local.URL)
+		case configs.StorageS3:
+			store, err = newS3Storage(cfg.Storage.S3)
+		default:
+			err = fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+		}
+		return err
+	})
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, store, jobs, cfg.Storage.AvatarMaxSize)
+
+	// Readiness probe
+	probe := health.NewProbe(db)
+
+	// Dependency checks for /health; the LLM gateway only degrades it
+	checks := health.NewRegistry()
+	checks.Register("database", db)
+	if cfg.LLM.Enabled {
+		checks.Register("llm", llm.New(cfg.LLM), health.Optional())
+	}
+
+	// Rate limiter, shared between replicas with the redis backend
+	var limiter middleware.Limiter
+	if cfg.RateLimit.Enabled {
+		bootPhase("rate_limit", exitConfig, func() error {
+			if err := cfg.RateLimit.Validate(); err != nil {
+				return err
+			}
+			switch cfg.RateLimit.Backend {
+			case configs.RateLimitMemory:
+				l := middleware.NewMemoryLimiter(rate.Limit(cfg.RateLimit.RPS), cfg.RateLimit.Burst)
+				limiter, setRateLimit = l, l.SetLimit
+			case configs.RateLimitRedis:
+				l := middleware.NewRedisLimiter(redisClient(), rate.Limit(cfg.RateLimit.RPS), cfg.RateLimit.Burst,
+					middleware.WithRedisKeyPrefix(cfg.RateLimit.KeyPrefix))
+				limiter, setRateLimit = l, l.SetLimit
+			default:
+				return fmt.Errorf("unknown rate limit backend %q", cfg.RateLimit.Backend)
+			}
+			return nil
+		})
+	}
+
+	// Lockout after failed logins, shared between replicas with the redis
+	// backend
+	var guard *lockout.Guard
+	if cfg.Auth.Lockout.Enabled {
+		bootPhase("lockout", exitConfig, func() error {
+			var store lockout.Store
+			switch cfg.Auth.Lockout.Backend {
+			case configs.LockoutMemory:
+				store = lockout.NewMemory()
+			case configs.LockoutRedis:
+				store = lockout.NewRedis(redisClient(), cfg.Auth.Lockout.KeyPrefix)
+			default:
+				return fmt.Errorf("unknown lockout backend %q", cfg.Auth.Lockout.Backend)
+			}
+			guard = lockout.New(store, lockout.Policy{
+				MaxFailures: cfg.Auth.Lockout.MaxFailures,
+				Window:      cfg.Auth.Lockout.Window,
+				Duration:    cfg.Auth.Lockout.Duration,
+			})
+			return nil
+		})
+	}
+	authHandler := handlers.NewAuthHandler(userService, guard, cfg.Auth)
+
+	if rdb != nil {
+		checks.Register("redis", health.CheckFunc(func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}), health.Optional())
+	}
+
+	// Apply config changes without a restart, on file edits and on SIGHUP
+	// (see server.WithOnReload below)
+	configs.Watch(applyConfig)
+
+	// Websocket connections, closed as shutdown begins. Half the drain
+	// window is left for their close handshakes.
+	hub := ws.NewHub(
+		ws.WithMaxMessageSize(cfg.WebSocket.MaxMessageSize),
+		ws.WithPingInterval(cfg.WebSocket.PingInterval),
+		ws.WithWriteTimeout(cfg.WebSocket.WriteTimeout),
+		ws.WithSendBuffer(cfg.WebSocket.SendBuffer),
+		ws.WithCloseTimeout(cfg.Server.ShutdownTimeout/2),
+		ws.WithOriginAllowed(func(origin string) bool {
+			return middleware.OriginAllowed(cfg.CORS.AllowedOrigins, origin)
+		}),
+	)
 
 	// Setup router
-	r := router.Setup(cfg, userHandler)
+	var r *gin.Engine
+	bootPhase("router", exitConfig, func() (err error) {
+		r, err = router.Setup(cfg, probe, checks, maintenance, level, limiter, hub, userHandler, authHandler)
+		return err
+	})
 
 	// Start server
-	srv := server.New(r,
+	opts := []server.Option{
 		server.WithPort(cfg.Server.Port),
 		server.WithReadTimeout(cfg.Server.ReadTimeout),
+		server.WithReadHeaderTimeout(cfg.Server.ReadHeaderTimeout),
 		server.WithWriteTimeout(cfg.Server.WriteTimeout),
-	)
+		server.WithIdleTimeout(cfg.Server.IdleTimeout),
+		server.WithMaxHeaderBytes(cfg.Server.MaxHeaderBytes),
+		server.WithShutdownDelay(cfg.Server.ShutdownDelay),
+		server.WithShutdownTimeout(cfg.Server.ShutdownTimeout),
+		server.WithOnReady(func(addr net.Addr) {
+			slog.Info("ready", "addr", addr.String(), "startup", time.Since(bootStart))
+		}),
+		server.WithOnReload(func() {
+			if err := configs.Reload(applyConfig); err != nil {
+				slog.Error("config reload failed, keeping current settings", "error", err)
+			}
+		}),
+		server.WithOnShutdownStart(probe.MarkShuttingDown),
+		server.WithOnShutdownStart(hub.Close),
+		server.WithOnShutdown(shutdownTracing),
+		server.WithOnShutdown(func(context.Context) error { return db.Close() }),
+		server.WithOnShutdown(func(context.Context) error {
+			if rdb == nil {
+				return nil
+			}
+			return rdb.Close()
+		}),
+		// Hooks run in reverse, so the dispatcher and queued jobs finish
+		// before the database and Redis close
+		server.WithOnShutdown(dispatcher.Stop),
+		server.WithOnShutdown(jobs.Stop),
+	}
+	if sentry != nil {
+		// Registered first so it runs last and sends what the others report
+		opts = append([]server.Option{server.WithOnShutdown(sentry.Flush)}, opts...)
+	}
+	switch {
+	case len(cfg.Server.TLS.AutoDomains) > 0:
+		opts = append(opts, server.WithAutoTLS(cfg.Server.TLS.AutoDomains...))
+	case cfg.Server.TLS.CertFile != "":
+		opts = append(opts, server.WithTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile))
+	}
+	srv := server.New(r, opts...)
 
+	listenStart := time.Now()
 	if err := srv.Run(); err != nil {
+		if errors.Is(err, server.ErrListen) {
+			bootFailed("listen", exitListen, err, time.Since(listenStart))
+		}
 		slog.Error("server error", "error", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 }
@@ -0,0 +1,109 @@
+// cmd/myapp/migrate.go
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/migrations"
+	"github.com/yourname/myapp/pkg/database"
+	"github.com/yourname/myapp/pkg/migrate"
+)
+
+// runMigrate implements `myapp migrate up|down|version`
+func runMigrate(db *database.Database, driver string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: myapp migrate up|down|version")
+	}
+
+	sqlDB, err := db.DB().DB()
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New(sqlDB, driver, migrations.FS)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down()
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+	return nil
+}
+
+// checkSchema compares the schema version of the database in cfg with the
+// latest migration in this build and, if it is behind, migrates, warns or
+// fails as cfg.SchemaCheck says
+func checkSchema(cfg configs.DatabaseConfig) error {
+	switch cfg.SchemaCheck {
+	case configs.SchemaCheckOff:
+		return nil
+	case configs.SchemaCheckWarn, configs.SchemaCheckFail, configs.SchemaCheckMigrate:
+	default:
+		return fmt.Errorf("unknown schema check %q (supported: off, warn, fail, migrate)", cfg.SchemaCheck)
+	}
+
+	// Closing the migrator closes its database, so it gets its own
+	db, err := database.New(database.FromConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sqlDB, err := db.DB().DB()
+	if err != nil {
+		db.Close()
+		return err
+	}
+	m, err := migrate.New(sqlDB, cfg.Driver, migrations.FS)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	defer m.Close()
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	slog.Info("schema version", "current", status.Current, "target", status.Latest, "dirty", status.Dirty)
+
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("%w at version %d; repair it, then run myapp migrate up", migrate.ErrDirty, status.Current)
+	case status.Ahead():
+		slog.Warn("schema is ahead of this build", "current", status.Current, "target", status.Latest)
+		return nil
+	case !status.Behind():
+		return nil
+	}
+
+	switch cfg.SchemaCheck {
+	case configs.SchemaCheckMigrate:
+		slog.Info("applying pending migrations", "current", status.Current, "target", status.Latest)
+		if err := m.Up(); err != nil {
+			return err
+		}
+		slog.Info("schema migrated", "version", status.Latest)
+	case configs.SchemaCheckWarn:
+		slog.Warn("schema is behind this build; run myapp migrate up", "current", status.Current, "target", status.Latest)
+	default:
+		return fmt.Errorf("schema version %d is behind %d; run myapp migrate up", status.Current, status.Latest)
+	}
+	return nil
+}
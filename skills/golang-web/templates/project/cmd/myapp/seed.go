@@ -0,0 +1,61 @@
+// cmd/myapp/seed.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// seedPassword is the password of every seeded user
+const seedPassword = "password123"
+
+// runSeed implements `myapp seed [-count N]`, inserting demo users with
+// deterministic emails so repeated runs skip existing rows
+func runSeed(ctx context.Context, repo repositories.UserRepository, mode string, args []string) error {
+	if mode == "release" {
+		return fmt.Errorf("refusing to seed in release mode")
+	}
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	count := fs.Int("count", 20, "number of users to create")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(seedPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	created, skipped := 0, 0
+	for i := 1; i <= *count; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+
+		existing, err := repo.FindByEmailWithDeleted(ctx, email)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", email, err)
+		}
+		if existing != nil {
+			skipped++
+			continue
+		}
+
+		if _, err := repo.Save(ctx, &models.User{
+			Email:    email,
+			Name:     fmt.Sprintf("User %d", i),
+			Password: string(hash),
+		}); err != nil {
+			return fmt.Errorf("failed to create %s: %w", email, err)
+		}
+		created++
+	}
+
+	slog.Info("seed complete", "created", created, "skipped", skipped, "password", seedPassword)
+	return nil
+}
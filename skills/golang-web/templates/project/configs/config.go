@@ -2,49 +2,455 @@
 package configs
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Log      LogConfig      `mapstructure:"log"`
-	LLM      LLMConfig      `mapstructure:"llm"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Log           LogConfig           `mapstructure:"log"`
+	CORS          CORSConfig          `mapstructure:"cors"`
+	SecureHeaders SecureHeadersConfig `mapstructure:"secure_headers"`
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`
+	Redis         RedisConfig         `mapstructure:"redis"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Compression   CompressionConfig   `mapstructure:"compression"`
+	WebSocket     WebSocketConfig     `mapstructure:"websocket"`
+	Response      ResponseConfig      `mapstructure:"response"`
+	Metrics       MetricsConfig       `mapstructure:"metrics"`
+	Tracing       TracingConfig       `mapstructure:"tracing"`
+	Alert         AlertConfig         `mapstructure:"alert"`
+	Idempotency   IdempotencyConfig   `mapstructure:"idempotency"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Tenancy       TenancyConfig       `mapstructure:"tenancy"`
+	Pprof         PprofConfig         `mapstructure:"pprof"`
+	Outbox        OutboxConfig        `mapstructure:"outbox"`
+	Worker        WorkerConfig        `mapstructure:"worker"`
+	Maintenance   MaintenanceConfig   `mapstructure:"maintenance"`
+	LLM           LLMConfig           `mapstructure:"llm"`
+	Storage       StorageConfig       `mapstructure:"storage"`
+
+	// Databases lists further named datastores; see DatabaseNamed
+	Databases map[string]DatabaseConfig `mapstructure:"databases"`
 }
 
 type ServerConfig struct {
-	Port         int           `mapstructure:"port"`
-	Mode         string        `mapstructure:"mode"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	Port              int             `mapstructure:"port"`
+	Mode              string          `mapstructure:"mode"`
+	ReadTimeout       time.Duration   `mapstructure:"read_timeout"`
+	ReadHeaderTimeout time.Duration   `mapstructure:"read_header_timeout"`
+	WriteTimeout      time.Duration   `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration   `mapstructure:"idle_timeout"`
+	MaxHeaderBytes    int             `mapstructure:"max_header_bytes"`
+	ShutdownDelay     time.Duration   `mapstructure:"shutdown_delay"`
+	ShutdownTimeout   time.Duration   `mapstructure:"shutdown_timeout"`
+	RequestTimeout    time.Duration   `mapstructure:"request_timeout"`
+	BodyLimit         BodyLimitConfig `mapstructure:"body_limit"`
+	TLS               TLSConfig       `mapstructure:"tls"`
+
+	// TrustedProxies lists the IPs and CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed when resolving the client IP
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	// BasePath prefixes every route, for serving behind a reverse proxy at
+	// a sub-path such as /myapp
+	BasePath string `mapstructure:"base_path"`
+}
+
+// BodyLimitConfig caps request body sizes in bytes per route group
+type BodyLimitConfig struct {
+	Default int64 `mapstructure:"default"`
+	Batch   int64 `mapstructure:"batch"`
+	Upload  int64 `mapstructure:"upload"`
+}
+
+type TLSConfig struct {
+	CertFile    string   `mapstructure:"cert_file"`
+	KeyFile     string   `mapstructure:"key_file"`
+	AutoDomains []string `mapstructure:"auto_domains"`
 }
 
+// Validate rejects a certificate without its key or the other way round,
+// which would otherwise serve plain HTTP
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("server.tls.cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// PrimaryDatabase names the database used by the app's own repositories
+const PrimaryDatabase = "primary"
+
 type DatabaseConfig struct {
-	Driver   string `mapstructure:"driver"`
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
-	Database string `mapstructure:"database"`
-	SSLMode  string `mapstructure:"ssl_mode"`
+	Driver          string        `mapstructure:"driver"`
+	Host            string        `mapstructure:"host"`
+	Port            int           `mapstructure:"port"`
+	Username        string        `mapstructure:"username"`
+	Password        string        `mapstructure:"password"`
+	Database        string        `mapstructure:"database"`
+	SSLMode         string        `mapstructure:"ssl_mode"`
+	Charset         string        `mapstructure:"charset"`
+	Loc             string        `mapstructure:"loc"`
+	Replicas        []string      `mapstructure:"replicas"`
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+
+	// StartupTimeout is how long startup keeps retrying an unreachable
+	// database before giving up
+	StartupTimeout     time.Duration `mapstructure:"startup_timeout"`
+	ReconnectInterval  time.Duration `mapstructure:"reconnect_interval"`
+	IDStrategy         string        `mapstructure:"id_strategy"`
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+
+	// TxRetry retries transactions aborted by serialization failures
+	TxRetry RetryConfig `mapstructure:"tx_retry"`
+
+	// SchemaCheck is what startup does when migrations are pending: one of
+	// the SchemaCheck constants
+	SchemaCheck string `mapstructure:"schema_check"`
+
+	// EncryptionKeys encrypt fields tagged gorm:"serializer:encrypted", as
+	// "<version>:<base64 key>" entries, newest first
+	EncryptionKeys []string `mapstructure:"encryption_keys"`
+
+	// StatementTimeout and LockTimeout bound each statement server-side on
+	// postgres; zero leaves the server's setting
+	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
+	LockTimeout      time.Duration `mapstructure:"lock_timeout"`
 }
 
+// Schema checks run on startup against the primary database
+const (
+	SchemaCheckOff     = "off"     // skip the check, e.g. when migrating out of band
+	SchemaCheckWarn    = "warn"    // log pending migrations and start anyway
+	SchemaCheckFail    = "fail"    // refuse to start
+	SchemaCheckMigrate = "migrate" // apply pending migrations, then start
+)
+
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level     string            `mapstructure:"level"`
+	Format    string            `mapstructure:"format"`
+	SkipPaths []string          `mapstructure:"skip_paths"`
+	Sampling  LogSamplingConfig `mapstructure:"sampling"`
+	Bodies    BodyLogConfig     `mapstructure:"bodies"`
+}
+
+// LogSamplingConfig thins the request log. Of the successful requests that
+// are not slow, 1 in Rate is logged at info and the rest at debug; errors
+// and requests slower than SlowThreshold are always logged. Routes sets the
+// rate of single routes, keyed by "METHOD /pattern" or "/pattern".
+type LogSamplingConfig struct {
+	Rate          int            `mapstructure:"rate"`
+	SlowThreshold time.Duration  `mapstructure:"slow_threshold"`
+	Routes        map[string]int `mapstructure:"routes"`
+}
+
+// BodyLogConfig controls logging of request and response bodies, which
+// only ever happens in debug mode
+type BodyLogConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	MaxSize int      `mapstructure:"max_size"`
+	Redact  []string `mapstructure:"redact"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+// SecureHeadersConfig sets security headers on every response. An empty
+// value, or a zero HSTSMaxAge, omits that header.
+type SecureHeadersConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`
+	ContentTypeOptions    string        `mapstructure:"content_type_options"`
+	FrameOptions          string        `mapstructure:"frame_options"`
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+	ReferrerPolicy        string        `mapstructure:"referrer_policy"`
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	HSTSPreload           bool          `mapstructure:"hsts_preload"`
+}
+
+// RateLimitConfig configures per-client rate limiting. The memory backend
+// limits each replica on its own; the redis backend shares limits between
+// replicas through the redis section.
+type RateLimitConfig struct {
+	Enabled   bool    `mapstructure:"enabled"`
+	Backend   string  `mapstructure:"backend"`
+	RPS       float64 `mapstructure:"rps"`
+	Burst     int     `mapstructure:"burst"`
+	KeyPrefix string  `mapstructure:"key_prefix"`
+}
+
+// Validate rejects limits no client could live with: an empty bucket
+// admits no request and a zero rate never refills one
+func (c RateLimitConfig) Validate() error {
+	if c.Burst < 1 {
+		return fmt.Errorf("rate_limit.burst must be at least 1, got %d", c.Burst)
+	}
+	if c.RPS <= 0 {
+		return fmt.Errorf("rate_limit.rps must be positive, got %v", c.RPS)
+	}
+	return nil
+}
+
+// Rate limit backends
+const (
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
+// CacheConfig configures caching of user lookups. The memory backend keeps
+// up to Size entries per replica; the redis backend shares one cache
+// through the redis section.
+type CacheConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Backend   string        `mapstructure:"backend"`
+	TTL       time.Duration `mapstructure:"ttl"`
+	Size      int           `mapstructure:"size"`
+	KeyPrefix string        `mapstructure:"key_prefix"`
+}
+
+// Cache backends
+const (
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// RedisConfig configures the Redis connection
+type RedisConfig struct {
+	Addr         string        `mapstructure:"addr"`
+	Password     string        `mapstructure:"password"`
+	DB           int           `mapstructure:"db"`
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Level   int  `mapstructure:"level"`
+	MinSize int  `mapstructure:"min_size"`
+}
+
+// WebSocketConfig configures websocket connections. Cross-origin upgrades
+// are accepted from cors.allowed_origins.
+type WebSocketConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	MaxMessageSize int64         `mapstructure:"max_message_size"`
+	PingInterval   time.Duration `mapstructure:"ping_interval"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
+	SendBuffer     int           `mapstructure:"send_buffer"`
+}
+
+// ResponseConfig shapes response bodies. FieldNaming is snake_case, as
+// the models are tagged, or camel_case.
+type ResponseConfig struct {
+	FieldNaming string `mapstructure:"field_naming"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRate  float64 `mapstructure:"sample_rate"`
+}
+
+// AlertConfig sends panics and server errors to an error tracker. Without
+// a SentryDSN they are only logged.
+type AlertConfig struct {
+	SentryDSN   string `mapstructure:"sentry_dsn"`
+	Environment string `mapstructure:"environment"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+type AuthConfig struct {
+	JWTSecret string        `mapstructure:"jwt_secret"`
+	Issuer    string        `mapstructure:"issuer"`
+	TokenTTL  time.Duration `mapstructure:"token_ttl"`
+	Lockout   LockoutConfig `mapstructure:"lockout"`
+}
+
+// LockoutConfig locks logins for an email or client IP after MaxFailures
+// failed attempts within Window. The memory backend counts per replica;
+// the redis backend shares counts through the redis section.
+type LockoutConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Backend     string        `mapstructure:"backend"`
+	MaxFailures int           `mapstructure:"max_failures"`
+	Window      time.Duration `mapstructure:"window"`
+	Duration    time.Duration `mapstructure:"duration"`
+	KeyPrefix   string        `mapstructure:"key_prefix"`
+}
+
+// Lockout backends
+const (
+	LockoutMemory = "memory"
+	LockoutRedis  = "redis"
+)
+
+// TenancyConfig scopes API requests to a tenant, taken from the first of
+// Header, the subdomain of Domain and the token's tenant claim that names
+// one; an empty Header or Domain, or a false Claim, skips that source.
+// Without tenancy every user belongs to the default tenant.
+type TenancyConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Header  string `mapstructure:"header"`
+	Domain  string `mapstructure:"domain"`
+	Claim   bool   `mapstructure:"claim"`
+}
+
+// PprofConfig exposes net/http/pprof under /debug/pprof to admins
+type PprofConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// OutboxConfig controls the background publisher of domain events
+type OutboxConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	PollInterval   time.Duration `mapstructure:"poll_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// WorkerConfig sizes the pool running background jobs. Jobs queued at
+// shutdown get what is left of server.shutdown_timeout to finish.
+type WorkerConfig struct {
+	Workers   int `mapstructure:"workers"`
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// MaintenanceConfig sets the initial state of maintenance mode, during
+// which the API answers 503. Admins can flip it at runtime.
+type MaintenanceConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	RetryAfter time.Duration `mapstructure:"retry_after"`
 }
 
+// LLMConfig configures the LLM gateway client. The app creates the client
+// and health-checks the gateway only when Enabled is set.
 type LLMConfig struct {
-	BaseURL      string `mapstructure:"base_url"`
-	APIKey       string `mapstructure:"api_key"`
-	DefaultModel string `mapstructure:"default_model"`
+	Enabled      bool        `mapstructure:"enabled"`
+	BaseURL      string      `mapstructure:"base_url"`
+	APIKey       string      `mapstructure:"api_key"`
+	DefaultModel string      `mapstructure:"default_model"`
+	Retry        RetryConfig `mapstructure:"retry"`
+}
+
+// StorageConfig selects where blobs such as uploads are kept. The local
+// backend writes to a directory the app serves itself; the s3 backend uses
+// a bucket, with credentials from the usual AWS environment variables,
+// shared config or instance role.
+type StorageConfig struct {
+	Backend string             `mapstructure:"backend"`
+	Local   LocalStorageConfig `mapstructure:"local"`
+	S3      S3StorageConfig    `mapstructure:"s3"`
+	// AvatarMaxSize caps avatar uploads in bytes
+	AvatarMaxSize int64 `mapstructure:"avatar_max_size"`
+}
+
+// LocalStorageConfig stores files under Dir with URLs starting with URL.
+// A URL path is served by the app, matched as is, so include the server's
+// base path in it; a full URL means something else serves Dir.
+type LocalStorageConfig struct {
+	Dir string `mapstructure:"dir"`
+	URL string `mapstructure:"url"`
+}
+
+// S3StorageConfig stores files in Bucket under Prefix. Endpoint and
+// PathStyle point the client at an S3-compatible store such as MinIO.
+// PublicURL is where clients fetch objects from; it defaults to the
+// bucket's own endpoint.
+type S3StorageConfig struct {
+	Bucket    string `mapstructure:"bucket"`
+	Region    string `mapstructure:"region"`
+	Prefix    string `mapstructure:"prefix"`
+	Endpoint  string `mapstructure:"endpoint"`
+	PathStyle bool   `mapstructure:"path_style"`
+	PublicURL string `mapstructure:"public_url"`
+}
+
+// Storage backends
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// LoadOption customizes Load, e.g. from command-line flags
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	file      string
+	overrides map[string]interface{}
+}
+
+// WithFile reads path instead of the file chosen by APP_ENV. Unlike that
+// file, path must exist.
+func WithFile(path string) LoadOption {
+	return func(o *loadOptions) {
+		o.file = path
+	}
 }
 
-func Load() *Config {
-	viper.SetConfigFile("config.yaml")
+// WithOverride sets key, e.g. "server.port", to value. Overrides take
+// precedence over the environment and the config file, and are kept when
+// the config is reloaded.
+func WithOverride(key string, value interface{}) LoadOption {
+	return func(o *loadOptions) {
+		o.overrides[key] = value
+	}
+}
+
+// Load reads configuration from defaults, the file chosen by APP_ENV (see
+// configFile), environment variables and overrides, in increasing order of
+// precedence
+func Load(opts ...LoadOption) (*Config, error) {
+	o := loadOptions{overrides: make(map[string]interface{})}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	file := o.file
+	if file == "" {
+		file = configFile(os.Getenv("APP_ENV"))
+	} else if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	viper.SetConfigFile(file)
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 
@@ -52,29 +458,266 @@ func Load() *Config {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("APP")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnv("", reflect.TypeOf(Config{}))
 
 	// Defaults
-	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.mode", "debug")
 	viper.SetDefault("server.read_timeout", 30*time.Second)
+	viper.SetDefault("server.read_header_timeout", 5*time.Second)
 	viper.SetDefault("server.write_timeout", 30*time.Second)
+	viper.SetDefault("server.idle_timeout", 2*time.Minute)
+	viper.SetDefault("server.max_header_bytes", 1<<20)
+	viper.SetDefault("server.shutdown_delay", 0)
+	viper.SetDefault("server.shutdown_timeout", 10*time.Second)
+	viper.SetDefault("server.request_timeout", 10*time.Second)
+	viper.SetDefault("server.body_limit.default", 1<<20)
+	viper.SetDefault("server.body_limit.batch", 4<<20)
+	viper.SetDefault("server.body_limit.upload", 10<<20)
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.base_path", "")
 
 	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.database", "data/app.db")
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime", 30*time.Minute)
+	viper.SetDefault("database.conn_max_idle_time", 5*time.Minute)
+	viper.SetDefault("database.startup_timeout", 30*time.Second)
+	viper.SetDefault("database.reconnect_interval", 5*time.Second)
+	viper.SetDefault("database.id_strategy", "uuid")
+	viper.SetDefault("database.slow_query_threshold", 200*time.Millisecond)
+	viper.SetDefault("database.tx_retry.max_attempts", 3)
+	viper.SetDefault("database.tx_retry.initial_backoff", 10*time.Millisecond)
+	viper.SetDefault("database.tx_retry.max_backoff", 200*time.Millisecond)
+	viper.SetDefault("database.schema_check", SchemaCheckFail)
 
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
+	viper.SetDefault("log.skip_paths", []string{"/health", "/livez", "/readyz", "/metrics"})
+	viper.SetDefault("log.sampling.rate", 1)
+	viper.SetDefault("log.sampling.slow_threshold", time.Second)
+	viper.SetDefault("log.bodies.enabled", false)
+	viper.SetDefault("log.bodies.max_size", 4096)
+	viper.SetDefault("log.bodies.redact", []string{})
+
+	viper.SetDefault("cors.allowed_origins", []string{})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("cors.allowed_headers", []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"})
+	viper.SetDefault("cors.exposed_headers", []string{"X-Request-ID", "X-Response-Time"})
+	viper.SetDefault("cors.allow_credentials", false)
+	viper.SetDefault("cors.max_age", 12*time.Hour)
+
+	viper.SetDefault("secure_headers.enabled", true)
+	viper.SetDefault("secure_headers.content_type_options", "nosniff")
+	viper.SetDefault("secure_headers.frame_options", "DENY")
+	viper.SetDefault("secure_headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	viper.SetDefault("secure_headers.referrer_policy", "no-referrer")
+	viper.SetDefault("secure_headers.hsts_max_age", 365*24*time.Hour)
+	viper.SetDefault("secure_headers.hsts_include_subdomains", true)
+	viper.SetDefault("secure_headers.hsts_preload", false)
+
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.rps", 10)
+	viper.SetDefault("rate_limit.burst", 20)
+	viper.SetDefault("rate_limit.backend", RateLimitMemory)
+	viper.SetDefault("rate_limit.key_prefix", "ratelimit:")
+
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.backend", CacheMemory)
+	viper.SetDefault("cache.ttl", time.Minute)
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.key_prefix", "cache:")
+
+	viper.SetDefault("redis.addr", "localhost:6379")
+	viper.SetDefault("redis.password", "")
+	viper.SetDefault("redis.db", 0)
+	viper.SetDefault("redis.dial_timeout", time.Second)
+	viper.SetDefault("redis.read_timeout", 200*time.Millisecond)
+	viper.SetDefault("redis.write_timeout", 200*time.Millisecond)
+
+	viper.SetDefault("compression.enabled", true)
+	viper.SetDefault("compression.level", -1)
+	viper.SetDefault("compression.min_size", 1024)
+
+	viper.SetDefault("websocket.enabled", true)
+	viper.SetDefault("websocket.max_message_size", 64<<10)
+	viper.SetDefault("websocket.ping_interval", 30*time.Second)
+	viper.SetDefault("websocket.write_timeout", 10*time.Second)
+	viper.SetDefault("websocket.send_buffer", 32)
+
+	viper.SetDefault("response.field_naming", "snake_case")
+
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.service_name", "myapp")
+	viper.SetDefault("tracing.sample_rate", 1.0)
+
+	viper.SetDefault("alert.sentry_dsn", "")
+	viper.SetDefault("alert.environment", "")
 
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+
+	viper.SetDefault("auth.token_ttl", time.Hour)
+	viper.SetDefault("auth.lockout.enabled", true)
+	viper.SetDefault("auth.lockout.backend", LockoutMemory)
+	viper.SetDefault("auth.lockout.max_failures", 5)
+	viper.SetDefault("auth.lockout.window", 15*time.Minute)
+	viper.SetDefault("auth.lockout.duration", 15*time.Minute)
+	viper.SetDefault("auth.lockout.key_prefix", "lockout:")
+
+	viper.SetDefault("tenancy.enabled", false)
+	viper.SetDefault("tenancy.header", "X-Tenant-ID")
+	viper.SetDefault("tenancy.domain", "")
+	viper.SetDefault("tenancy.claim", true)
+
+	viper.SetDefault("pprof.enabled", false)
+
+	viper.SetDefault("outbox.enabled", true)
+	viper.SetDefault("outbox.poll_interval", time.Second)
+	viper.SetDefault("outbox.batch_size", 100)
+	viper.SetDefault("outbox.initial_backoff", time.Second)
+	viper.SetDefault("outbox.max_backoff", 5*time.Minute)
+
+	viper.SetDefault("worker.workers", 4)
+	viper.SetDefault("worker.queue_size", 100)
+
+	viper.SetDefault("maintenance.enabled", false)
+	viper.SetDefault("maintenance.retry_after", 2*time.Minute)
+
+	viper.SetDefault("llm.enabled", false)
 	viper.SetDefault("llm.base_url", "http://localhost:4000")
 	viper.SetDefault("llm.default_model", "gpt-4o")
+	viper.SetDefault("llm.retry.max_attempts", 3)
+	viper.SetDefault("llm.retry.initial_backoff", 500*time.Millisecond)
+	viper.SetDefault("llm.retry.max_backoff", 5*time.Second)
+
+	viper.SetDefault("storage.backend", StorageLocal)
+	viper.SetDefault("storage.local.dir", "data/uploads")
+	viper.SetDefault("storage.local.url", "/uploads")
+	viper.SetDefault("storage.avatar_max_size", 2<<20)
+
+	for key, value := range o.overrides {
+		viper.Set(key, value)
+	}
 
-	// Read config file (optional)
-	_ = viper.ReadInConfig()
+	// Read config file (optional, but must parse if present)
+	if err := viper.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
 
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
-		panic("failed to unmarshal config: " + err.Error())
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	return &cfg
+	return &cfg, nil
+}
+
+// DatabaseNamed returns the config of a database listed under databases.
+// The primary database may also be configured in the top-level database
+// section, which is used when databases.primary is absent. Only the
+// top-level section gets defaults; named entries are used as written.
+func (c *Config) DatabaseNamed(name string) (DatabaseConfig, bool) {
+	if db, ok := c.Databases[name]; ok {
+		return db, true
+	}
+	if name == PrimaryDatabase {
+		return c.Database, true
+	}
+	return DatabaseConfig{}, false
+}
+
+// reloadMu serializes reloads from Watch and Reload, so fn never runs
+// concurrently with itself
+var reloadMu sync.Mutex
+
+// Watch reloads the config file whenever it changes and passes a freshly
+// unmarshaled Config to fn. Each reload yields a new value, so readers of a
+// previously returned Config never observe a partial update.
+//
+// Only settings the app re-applies in fn take effect without a restart:
+// log.level, maintenance.enabled, and rate_limit.rps and burst when rate
+// limiting was enabled at startup.
+func Watch(fn func(*Config)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+
+		var cfg Config
+		if err := viper.Unmarshal(&cfg); err != nil {
+			slog.Error("failed to reload config", "file", e.Name, "error", err)
+			return
+		}
+		slog.Info("config reloaded", "file", e.Name)
+		fn(&cfg)
+	})
+	viper.WatchConfig()
+}
+
+// Reload rereads the config file and passes the result to fn as Watch
+// does, for reloading on demand, e.g. on SIGHUP. On error fn is not called
+// and the current settings stay in effect.
+func Reload(fn func(*Config)) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if err := viper.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	slog.Info("config reloaded", "file", viper.ConfigFileUsed())
+	fn(&cfg)
+	return nil
+}
+
+// bindEnv binds each key of the struct type t, nested under prefix, to its
+// environment variable. AutomaticEnv alone only covers keys viper already
+// knows from a default or the config file, so overrides of any other key
+// were silently dropped. Maps such as databases have no fixed keys and can
+// only be set in the file.
+func bindEnv(prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			bindEnv(key, f.Type)
+		case reflect.Map:
+		default:
+			_ = viper.BindEnv(key, envVar(key))
+		}
+	}
+}
+
+// envVar returns the environment variable overriding key, e.g.
+// APP_DATABASE_SSL_MODE for database.ssl_mode
+func envVar(key string) string {
+	return "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// configFile returns config.<env>.yaml when env is set and that file exists,
+// falling back to config.yaml
+func configFile(env string) string {
+	if env != "" {
+		name := fmt.Sprintf("config.%s.yaml", env)
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return "config.yaml"
 }
@@ -0,0 +1,142 @@
+// configs/config_test.go
+package configs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// chdirTemp runs the test in an empty directory holding a config.yaml with
+// content, resetting viper afterwards
+func chdirTemp(t *testing.T, content string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/config.yaml", []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+		viper.Reset()
+	})
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	chdirTemp(t, `
+database:
+  host: file-host
+  ssl_mode: disable
+`)
+	t.Setenv("APP_DATABASE_HOST", "env-host")
+	t.Setenv("APP_DATABASE_SSL_MODE", "require")
+	// Neither in the file nor defaulted
+	t.Setenv("APP_DATABASE_PASSWORD", "env-password")
+	t.Setenv("APP_AUTH_LOCKOUT_MAX_FAILURES", "7")
+	t.Setenv("APP_CORS_ALLOWED_ORIGINS", "https://a.example.com,https://b.example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Database.Host != "env-host" {
+		t.Errorf("database.host = %q, want env-host", cfg.Database.Host)
+	}
+	if cfg.Database.SSLMode != "require" {
+		t.Errorf("database.ssl_mode = %q, want require", cfg.Database.SSLMode)
+	}
+	if cfg.Database.Password != "env-password" {
+		t.Errorf("database.password = %q, want env-password", cfg.Database.Password)
+	}
+	if cfg.Auth.Lockout.MaxFailures != 7 {
+		t.Errorf("auth.lockout.max_failures = %d, want 7", cfg.Auth.Lockout.MaxFailures)
+	}
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("cors.allowed_origins = %q, want %q", cfg.CORS.AllowedOrigins, want)
+	}
+}
+
+func TestLoadFileWithoutEnv(t *testing.T) {
+	chdirTemp(t, `
+database:
+  host: file-host
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Database.Host != "file-host" {
+		t.Errorf("database.host = %q, want file-host", cfg.Database.Host)
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	chdirTemp(t, `
+server:
+  port: 8080
+`)
+	if err := os.WriteFile("other.yaml", []byte("server:\n  mode: release\n  port: 8081\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("APP_SERVER_PORT", "9090")
+
+	cfg, err := Load(WithFile("other.yaml"), WithOverride("server.port", 7070))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Mode != "release" {
+		t.Errorf("server.mode = %q, want release from other.yaml", cfg.Server.Mode)
+	}
+	if cfg.Server.Port != 7070 {
+		t.Errorf("server.port = %d, want the override over env and file", cfg.Server.Port)
+	}
+
+	viper.Reset()
+	if _, err := Load(WithFile("missing.yaml")); err == nil {
+		t.Error("Load of a missing explicit file succeeded")
+	}
+}
+
+func TestTLSValidate(t *testing.T) {
+	tests := []struct {
+		cfg   TLSConfig
+		valid bool
+	}{
+		{TLSConfig{}, true},
+		{TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}, true},
+		{TLSConfig{CertFile: "cert.pem"}, false},
+		{TLSConfig{KeyFile: "key.pem"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.cfg, err, tt.valid)
+		}
+	}
+}
+
+func TestRateLimitValidate(t *testing.T) {
+	tests := []struct {
+		cfg   RateLimitConfig
+		valid bool
+	}{
+		{RateLimitConfig{RPS: 10, Burst: 20}, true},
+		{RateLimitConfig{RPS: 10, Burst: 0}, false},
+		{RateLimitConfig{RPS: 0, Burst: 20}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", tt.cfg, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// internal/handlers/bind.go
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// bind decodes the request into obj with b and validates it against its
+// binding tags. On failure it responds 400 with field-level details, the
+// same for every source, and returns false; the handler should return.
+func bind(c *gin.Context, obj interface{}, b binding.Binding) bool {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		response.Error(c, errors.FromBinding(err))
+		return false
+	}
+	return true
+}
+
+// bindJSON binds a JSON body using json tags
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	return bind(c, obj, binding.JSON)
+}
+
+// bindQuery binds the query string using form tags
+func bindQuery(c *gin.Context, obj interface{}) bool {
+	return bind(c, obj, binding.Query)
+}
+
+// bindForm binds a urlencoded or multipart form body, and the query
+// string, using form tags
+func bindForm(c *gin.Context, obj interface{}) bool {
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		return bind(c, obj, binding.FormMultipart)
+	}
+	return bind(c, obj, binding.Form)
+}
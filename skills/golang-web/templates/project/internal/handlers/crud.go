@@ -0,0 +1,216 @@
+// internal/handlers/crud.go
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/pagination"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// DeleteQuery is the query string of DELETE on a CRUD resource
+type DeleteQuery struct {
+	Hard bool `form:"hard"`
+}
+
+// CRUDService is the service a CRUD handler drives. services.UserService
+// is one; a new resource's service needs only these methods.
+type CRUDService[TModel, TCreate, TUpdate any] interface {
+	Create(ctx context.Context, input TCreate) (*TModel, error)
+	GetByID(ctx context.Context, id string) (*TModel, error)
+	Update(ctx context.Context, id string, input TUpdate) (*TModel, error)
+	// Delete soft-deletes the resource, or with hard removes it for good
+	Delete(ctx context.Context, id string, hard bool) error
+	// List returns the page p describes and the total count
+	List(ctx context.Context, p pagination.Params) ([]*TModel, int64, error)
+}
+
+// CursorLister is implemented by services that also page by cursor. List
+// switches to it when the request has a cursor parameter.
+type CursorLister[TModel any] interface {
+	ListAfter(ctx context.Context, cursor string, limit int) ([]*TModel, string, error)
+}
+
+// CRUDOption configures a CRUD handler
+type CRUDOption[TModel any] func(*crudOptions[TModel])
+
+type crudOptions[TModel any] struct {
+	maxPageSize int
+	sortable    []string
+	etag        func(*TModel) string
+}
+
+// WithMaxPageSize caps the page size of List
+func WithMaxPageSize[TModel any](n int) CRUDOption[TModel] {
+	return func(o *crudOptions[TModel]) {
+		o.maxPageSize = n
+	}
+}
+
+// WithSortable whitelists the columns List may sort by; without it List
+// takes no sort parameter
+func WithSortable[TModel any](columns ...string) CRUDOption[TModel] {
+	return func(o *crudOptions[TModel]) {
+		o.sortable = columns
+	}
+}
+
+// WithETag makes Get send the entity tag fn returns and answer 304 when
+// the client already has it, e.g. with response.WeakETag
+func WithETag[TModel any](fn func(*TModel) string) CRUDOption[TModel] {
+	return func(o *crudOptions[TModel]) {
+		o.etag = fn
+	}
+}
+
+// CRUD implements the create, get, update, delete and list endpoints of a
+// resource against its service, so a new resource needs no handler code
+// of its own beyond API docs. Inputs are bound from JSON and validated by
+// their binding tags; the resource ID is the :id path parameter.
+type CRUD[TModel, TCreate, TUpdate any] struct {
+	service CRUDService[TModel, TCreate, TUpdate]
+	opts    crudOptions[TModel]
+}
+
+// NewCRUD creates a CRUD handler for service
+func NewCRUD[TModel, TCreate, TUpdate any](service CRUDService[TModel, TCreate, TUpdate], opts ...CRUDOption[TModel]) *CRUD[TModel, TCreate, TUpdate] {
+	h := &CRUD[TModel, TCreate, TUpdate]{
+		service: service,
+		opts:    crudOptions[TModel]{maxPageSize: maxPageSize},
+	}
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+	return h
+}
+
+// Create binds a TCreate and responds 201 with the created resource
+func (h *CRUD[TModel, TCreate, TUpdate]) Create(c *gin.Context) {
+	var input TCreate
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	item, err := h.service.Create(c.Request.Context(), input)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, item)
+}
+
+// Get responds with the resource, or 304 if WithETag is set and the client
+// has the current version
+func (h *CRUD[TModel, TCreate, TUpdate]) Get(c *gin.Context) {
+	item, err := h.service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if h.opts.etag != nil && response.NotModified(c, h.opts.etag(item)) {
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// Update binds a TUpdate and responds with the updated resource
+func (h *CRUD[TModel, TCreate, TUpdate]) Update(c *gin.Context) {
+	var input TUpdate
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	item, err := h.service.Update(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, item)
+}
+
+// Delete responds 204, hard deleting when the query has hard=true
+func (h *CRUD[TModel, TCreate, TUpdate]) Delete(c *gin.Context) {
+	var query DeleteQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), query.Hard); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// List pages by number, or by cursor when the request has a cursor
+// parameter and the service is a CursorLister
+func (h *CRUD[TModel, TCreate, TUpdate]) List(c *gin.Context) {
+	p, err := pagination.Parse(c,
+		pagination.WithMaxPageSize(h.opts.maxPageSize),
+		pagination.WithSortable(h.opts.sortable...),
+	)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if lister, ok := h.service.(CursorLister[TModel]); ok {
+			items, next, err := lister.ListAfter(c.Request.Context(), cursor, p.PageSize)
+			if err != nil {
+				response.Error(c, err)
+				return
+			}
+			response.CursorPaginated(c, items, next)
+			return
+		}
+	}
+
+	items, total, err := h.service.List(c.Request.Context(), p)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Paginated(c, items, total, p.Page, p.PageSize)
+}
+
+// CRUDRoutes are the endpoints RegisterCRUD registers. CRUD implements
+// them, as do resource handlers that wrap one to attach API docs.
+type CRUDRoutes interface {
+	Create(c *gin.Context)
+	Get(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	List(c *gin.Context)
+}
+
+// CRUDMiddleware adds middleware to some of the routes RegisterCRUD
+// registers, e.g. auth on writes only. Read covers Get and List.
+type CRUDMiddleware struct {
+	Create []gin.HandlerFunc
+	Read   []gin.HandlerFunc
+	Update []gin.HandlerFunc
+	Delete []gin.HandlerFunc
+}
+
+// RegisterCRUD registers h on g: POST and GET on the group's path, and
+// GET, PUT and DELETE on /:id
+func RegisterCRUD(g *gin.RouterGroup, h CRUDRoutes, mw CRUDMiddleware) {
+	g.POST("", chain(mw.Create, h.Create)...)
+	g.GET("", chain(mw.Read, h.List)...)
+	g.GET("/:id", chain(mw.Read, h.Get)...)
+	g.PUT("/:id", chain(mw.Update, h.Update)...)
+	g.DELETE("/:id", chain(mw.Delete, h.Delete)...)
+}
+
+// chain returns mw followed by handler, without sharing mw's array
+func chain(mw []gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+	return append(mw[:len(mw):len(mw)], handler)
+}
@@ -0,0 +1,80 @@
+// internal/handlers/log_level.go
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/auth"
+	"github.com/yourname/myapp/pkg/logger"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// LogLevelStatus reports the minimum level being logged
+type LogLevelStatus struct {
+	Level string `json:"level" example:"info"`
+}
+
+// SetLogLevelInput changes the minimum level being logged
+type SetLogLevelInput struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error" example:"debug"`
+}
+
+// LogLevelHandler reads and changes the level behind the default logger
+type LogLevelHandler struct {
+	level *slog.LevelVar
+}
+
+// NewLogLevelHandler creates a new LogLevelHandler
+func NewLogLevelHandler(level *slog.LevelVar) *LogLevelHandler {
+	return &LogLevelHandler{level: level}
+}
+
+// Get handles GET /admin/log-level
+//
+//	@Summary	Get log level
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	response.Response{data=LogLevelStatus}
+//	@Failure	401	{object}	response.Response
+//	@Failure	403	{object}	response.Response
+//	@Router		/admin/log-level [get]
+func (h *LogLevelHandler) Get(c *gin.Context) {
+	response.Success(c, LogLevelStatus{Level: logger.LevelName(h.level.Level())})
+}
+
+// Set handles PUT /admin/log-level
+//
+//	@Summary		Change log level
+//	@Description	Takes effect immediately and lasts until set again, the process restarts or log.level is edited in the config file.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			level	body		SetLogLevelInput	true	"Desired level"
+//	@Success		200		{object}	response.Response{data=LogLevelStatus}
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Router			/admin/log-level [put]
+func (h *LogLevelHandler) Set(c *gin.Context) {
+	var input SetLogLevelInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	lvl, _ := logger.ParseLevel(input.Level)
+	if prev := h.level.Level(); prev != lvl {
+		var by string
+		if user, ok := auth.UserFromContext(c.Request.Context()); ok {
+			by = user.ID
+		}
+		h.level.Set(lvl)
+		// Logged at warn so the change shows up whatever the new level
+		slog.WarnContext(c.Request.Context(), "log level changed",
+			"from", logger.LevelName(prev), "to", input.Level, "by", by)
+	}
+
+	response.Success(c, LogLevelStatus{Level: logger.LevelName(lvl)})
+}
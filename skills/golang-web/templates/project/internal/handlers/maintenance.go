@@ -0,0 +1,76 @@
+// internal/handlers/maintenance.go
+package handlers
+
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/auth"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// MaintenanceStatus reports whether maintenance mode is on
+type MaintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetMaintenanceInput turns maintenance mode on or off
+type SetMaintenanceInput struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceHandler reads and flips the flag behind middleware.Maintenance
+type MaintenanceHandler struct {
+	enabled *atomic.Bool
+}
+
+// NewMaintenanceHandler creates a new MaintenanceHandler
+func NewMaintenanceHandler(enabled *atomic.Bool) *MaintenanceHandler {
+	return &MaintenanceHandler{enabled: enabled}
+}
+
+// Get handles GET /admin/maintenance
+//
+//	@Summary	Get maintenance mode
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	response.Response{data=MaintenanceStatus}
+//	@Failure	401	{object}	response.Response
+//	@Failure	403	{object}	response.Response
+//	@Router		/admin/maintenance [get]
+func (h *MaintenanceHandler) Get(c *gin.Context) {
+	response.Success(c, MaintenanceStatus{Enabled: h.enabled.Load()})
+}
+
+// Set handles PUT /admin/maintenance
+//
+//	@Summary		Turn maintenance mode on or off
+//	@Description	While on, every request except health probes and this endpoint gets 503 with Retry-After.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			status	body		SetMaintenanceInput	true	"Desired state"
+//	@Success		200		{object}	response.Response{data=MaintenanceStatus}
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Router			/admin/maintenance [put]
+func (h *MaintenanceHandler) Set(c *gin.Context) {
+	var input SetMaintenanceInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	if h.enabled.Swap(*input.Enabled) != *input.Enabled {
+		var by string
+		if user, ok := auth.UserFromContext(c.Request.Context()); ok {
+			by = user.ID
+		}
+		slog.InfoContext(c.Request.Context(), "maintenance mode changed", "enabled", *input.Enabled, "by", by)
+	}
+
+	response.Success(c, MaintenanceStatus{Enabled: *input.Enabled})
+}
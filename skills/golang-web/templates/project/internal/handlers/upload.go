@@ -0,0 +1,111 @@
+// internal/handlers/upload.go
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+	"github.com/yourname/myapp/pkg/storage"
+)
+
+// sniffLen is how many bytes http.DetectContentType looks at
+const sniffLen = 512
+
+// imageTypes are the content types accepted for images
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// uploadPolicy limits what an upload endpoint accepts. The request body as
+// a whole is capped by the route's BodyLimit; MaxSize caps the file itself.
+type uploadPolicy struct {
+	MaxSize      int64
+	ContentTypes []string
+}
+
+// upload is a validated file from a multipart form
+type upload struct {
+	multipart.File
+	Size        int64
+	ContentType string
+}
+
+// formFile opens the file in the multipart form field and checks it
+// against policy. The content type is sniffed from the data, so a client
+// cannot pass off a file by mislabeling it. On failure it responds and
+// returns false; otherwise the caller must close the file.
+func formFile(c *gin.Context, field string, policy uploadPolicy) (*upload, bool) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		switch {
+		case errors.Is(err, http.ErrMissingFile):
+			response.Error(c, errors.InvalidParams(errors.FieldError{
+				Field: field, Tag: "required", Message: field + " is required",
+			}))
+		case errors.Is(err, http.ErrNotMultipart):
+			response.Error(c, errors.UnsupportedMediaType("request must be multipart/form-data"))
+		default:
+			response.Error(c, errors.FromBinding(err))
+		}
+		return nil, false
+	}
+	if header.Size > policy.MaxSize {
+		response.Error(c, errors.TooLarge(fmt.Sprintf("%s exceeds %d bytes", field, policy.MaxSize)))
+		return nil, false
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		response.Error(c, errors.Wrap(err, errors.KindInternal, "failed to read upload"))
+		return nil, false
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		response.Error(c, errors.Wrap(err, errors.KindInternal, "failed to read upload"))
+		return nil, false
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if !slices.Contains(policy.ContentTypes, contentType) {
+		f.Close()
+		response.Error(c, errors.UnsupportedMediaType(fmt.Sprintf("%s must be one of %v, got %s", field, policy.ContentTypes, contentType)))
+		return nil, false
+	}
+
+	return &upload{File: f, Size: header.Size, ContentType: contentType}, true
+}
+
+// digest returns the hex SHA-256 of the upload's content, leaving the file
+// at its start
+func (u *upload) digest() (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, u.File); err != nil {
+		return "", err
+	}
+	if _, err := u.File.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// put stores the upload under key
+func (u *upload) put(ctx context.Context, store storage.Storage, key string) (storage.Object, error) {
+	obj, err := store.Put(ctx, key, u.File, u.Size, u.ContentType)
+	if err != nil {
+		return storage.Object{}, errors.Wrap(err, errors.KindInternal, "failed to store upload")
+	}
+	return obj, nil
+}
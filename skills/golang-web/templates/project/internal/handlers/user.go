@@ -2,44 +2,192 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourname/myapp/internal/models"
 	"github.com/yourname/myapp/internal/services"
 	"github.com/yourname/myapp/pkg/errors"
 	"github.com/yourname/myapp/pkg/response"
+	"github.com/yourname/myapp/pkg/storage"
+	"github.com/yourname/myapp/pkg/worker"
 )
 
-// UserHandler handles user-related HTTP requests
+// maxPageSize caps the page size of list endpoints by default
+const maxPageSize = 100
+
+// userSortColumns are the columns GET /users may sort by
+var userSortColumns = []string{"created_at", "name", "email"}
+
+// maxBatchSize caps how many users one batch request may create
+const maxBatchSize = 100
+
+// ExportUsersQuery is the query string of GET /admin/users/export
+type ExportUsersQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json ndjson"`
+}
+
+// UserHandler handles user-related HTTP requests. The CRUD endpoints are
+// a configured CRUD handler; the methods for them only carry the API docs.
 type UserHandler struct {
+	crud    *CRUD[models.User, services.CreateUserInput, services.UpdateUserInput]
 	service services.UserService
+	store   storage.Storage
+	jobs    *worker.Pool
+	avatar  uploadPolicy
 }
 
-// NewUserHandler creates a new UserHandler
-func NewUserHandler(service services.UserService) *UserHandler {
-	return &UserHandler{service: service}
+// NewUserHandler creates a new UserHandler. Avatars are kept in store and
+// may be up to avatarMaxSize bytes; replaced ones are deleted on jobs.
+func NewUserHandler(service services.UserService, store storage.Storage, jobs *worker.Pool, avatarMaxSize int64) *UserHandler {
+	return &UserHandler{
+		crud: NewCRUD[models.User, services.CreateUserInput, services.UpdateUserInput](service,
+			WithSortable[models.User](userSortColumns...),
+			WithETag(func(user *models.User) string {
+				return response.WeakETag(user.ID, strconv.Itoa(user.Version), user.UpdatedAt.UTC().Format(time.RFC3339Nano))
+			}),
+		),
+		service: service,
+		store:   store,
+		jobs:    jobs,
+		avatar:  uploadPolicy{MaxSize: avatarMaxSize, ContentTypes: imageTypes},
+	}
 }
 
 // Create handles POST /users
+//
+//	@Summary	Create a user
+//	@Tags		users
+//	@Accept		json
+//	@Produce	json
+//	@Param		user	body		services.CreateUserInput	true	"User to create"
+//	@Success	201		{object}	response.Response{data=models.User}
+//	@Failure	400		{object}	response.Response
+//	@Failure	409		{object}	response.Response
+//	@Router		/users [post]
 func (h *UserHandler) Create(c *gin.Context) {
-	var input services.CreateUserInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		response.Error(c, errors.ErrInvalidParams)
+	h.crud.Create(c)
+}
+
+// CreateBatch handles POST /users/batch
+//
+//	@Summary		Create users in bulk
+//	@Description	Creates all users in one transaction; admin only. If any item fails, none are created and details lists each failed item by index.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			users	body		services.CreateUserBatchInput	true	"Users to create"
+//	@Success		201		{object}	response.Response{data=[]models.User}
+//	@Failure		400		{object}	response.Response{details=[]response.ItemError}
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Failure		409		{object}	response.Response{details=[]response.ItemError}
+//	@Failure		413		{object}	response.Response
+//	@Router			/users/batch [post]
+func (h *UserHandler) CreateBatch(c *gin.Context) {
+	var input services.CreateUserBatchInput
+	if !bindJSON(c, &input) {
+		return
+	}
+	if len(input.Users) > maxBatchSize {
+		response.Error(c, errors.TooLarge(fmt.Sprintf("batch exceeds %d users", maxBatchSize)))
 		return
 	}
 
-	user, err := h.service.Create(c.Request.Context(), input)
-	if err != nil {
-		response.Error(c, err)
+	errs := make([]error, len(input.Users))
+	invalid := false
+	for i := range input.Users {
+		if err := binding.Validator.ValidateStruct(&input.Users[i]); err != nil {
+			errs[i], invalid = errors.FromBinding(err), true
+		}
+	}
+	if invalid {
+		response.BatchError(c, errs)
 		return
 	}
 
-	response.Created(c, user)
+	users, errs := h.service.CreateBatch(c.Request.Context(), input.Users)
+	if errs != nil {
+		response.BatchError(c, errs)
+		return
+	}
+
+	response.Created(c, users)
 }
 
 // Get handles GET /users/:id
+//
+//	@Summary	Get a user
+//	@Tags		users
+//	@Produce	json
+//	@Param		id				path		string	true	"User ID"
+//	@Param		If-None-Match	header		string	false	"ETag from a previous response"
+//	@Success	200				{object}	response.Response{data=models.User}
+//	@Header		200				{string}	ETag	"Weak entity tag of the user"
+//	@Success	304
+//	@Failure	404	{object}	response.Response
+//	@Router		/users/{id} [get]
 func (h *UserHandler) Get(c *gin.Context) {
+	h.crud.Get(c)
+}
+
+// Update handles PUT /users/:id
+//
+//	@Summary		Replace a user
+//	@Description	Sets every writable field; use PATCH to change only some.
+//	@Description	Callers may update only themselves unless they are an admin.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string						true	"User ID"
+//	@Param			user	body		services.UpdateUserInput	true	"New field values"
+//	@Success		200		{object}	response.Response{data=models.User}
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Failure		404		{object}	response.Response
+//	@Failure		409		{object}	response.Response
+//	@Router			/users/{id} [put]
+func (h *UserHandler) Update(c *gin.Context) {
+	h.crud.Update(c)
+}
+
+// Patch handles PATCH /users/:id
+//
+//	@Summary		Update some fields of a user
+//	@Description	Changes only the fields present in the body; omitted or null
+//	@Description	fields keep their value. Callers may update only themselves
+//	@Description	unless they are an admin.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"User ID"
+//	@Param			user	body		services.PatchUserInput	true	"Fields to change"
+//	@Success		200		{object}	response.Response{data=models.User}
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Failure		404		{object}	response.Response
+//	@Failure		409		{object}	response.Response
+//	@Router			/users/{id} [patch]
+func (h *UserHandler) Patch(c *gin.Context) {
 	id := c.Param("id")
 
-	user, err := h.service.GetByID(c.Request.Context(), id)
+	var input services.PatchUserInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	user, err := h.service.Patch(c.Request.Context(), id, input)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -48,33 +196,170 @@ func (h *UserHandler) Get(c *gin.Context) {
 	response.Success(c, user)
 }
 
-// Update handles PUT /users/:id
-func (h *UserHandler) Update(c *gin.Context) {
+// Delete handles DELETE /users/:id
+//
+//	@Summary		Delete a user
+//	@Description	Callers may delete only themselves unless they are an admin.
+//	@Description	Users are soft-deleted: they disappear from the API but keep
+//	@Description	their email reserved, and deleting one again returns 404.
+//	@Description	Admins may pass hard=true to remove the user for good, which
+//	@Description	also works on soft-deleted users and frees the email; it
+//	@Description	returns 404 only once the user is gone entirely.
+//	@Tags			users
+//	@Security		BearerAuth
+//	@Param			id		path	string	true	"User ID"
+//	@Param			hard	query	bool	false	"Remove permanently (admin only)"
+//	@Success		204
+//	@Failure		400	{object}	response.Response
+//	@Failure		401	{object}	response.Response
+//	@Failure		403	{object}	response.Response
+//	@Failure		404	{object}	response.Response
+//	@Router			/users/{id} [delete]
+func (h *UserHandler) Delete(c *gin.Context) {
+	h.crud.Delete(c)
+}
+
+// Avatar handles PUT /users/:id/avatar
+//
+//	@Summary		Upload a user's avatar
+//	@Description	Replaces the avatar with a PNG, JPEG, GIF or WebP image. The type is detected from the file's content.
+//	@Description	Each image is served at a URL of its own, so the old URL never shows the new image.
+//	@Description	Callers may change only their own avatar unless they are an admin.
+//	@Tags			users
+//	@Accept			mpfd
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"User ID"
+//	@Param			avatar	formData	file	true	"Avatar image"
+//	@Success		200		{object}	response.Response{data=models.User}
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Failure		404		{object}	response.Response
+//	@Failure		413		{object}	response.Response
+//	@Failure		415		{object}	response.Response
+//	@Router			/users/{id}/avatar [put]
+func (h *UserHandler) Avatar(c *gin.Context) {
 	id := c.Param("id")
 
-	var input services.UpdateUserInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		response.Error(c, errors.ErrInvalidParams)
+	file, ok := formFile(c, "avatar", h.avatar)
+	if !ok {
 		return
 	}
+	defer file.Close()
 
-	user, err := h.service.Update(c.Request.Context(), id, input)
+	// A key per image, so browsers and CDNs caching the old URL never
+	// serve the old avatar as the new one
+	digest, err := file.digest()
+	if err != nil {
+		response.Error(c, errors.Wrap(err, errors.KindInternal, "failed to read upload"))
+		return
+	}
+	key := "avatars/" + id + "/" + digest[:16]
+
+	ctx := c.Request.Context()
+	var previous string
+	user, err := h.service.SetAvatar(ctx, id, func(ctx context.Context, current string) (string, error) {
+		previous = current
+		obj, err := file.put(ctx, h.store, key)
+		return obj.URL, err
+	})
 	if err != nil {
 		response.Error(c, err)
 		return
 	}
 
+	// Nothing refers to the old image once the new URL is saved, and the
+	// response need not wait for it to go. One left behind when the queue
+	// is full costs only space.
+	if old, ok := h.avatarKey(id, previous); ok && old != key {
+		err := h.jobs.Submit(func(ctx context.Context) error {
+			if err := h.store.Delete(ctx, old); err != nil {
+				return fmt.Errorf("delete previous avatar %s: %w", old, err)
+			}
+			return nil
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "failed to queue deleting the previous avatar", "key", old, "error", err)
+		}
+	}
+
 	response.Success(c, user)
 }
 
-// Delete handles DELETE /users/:id
-func (h *UserHandler) Delete(c *gin.Context) {
-	id := c.Param("id")
+// avatarKey returns the storage key of id's avatar served at url, or false
+// if url is not one
+func (h *UserHandler) avatarKey(id, url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, h.store.URL(""))
+	if !ok || (key != "avatars/"+id && !strings.HasPrefix(key, "avatars/"+id+"/")) {
+		return "", false
+	}
+	return key, true
+}
 
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		response.Error(c, err)
+// List handles GET /users
+//
+//	@Summary		List users
+//	@Description	Pages by number by default. Passing cursor (empty for the first
+//	@Description	page) switches to cursor mode, which ignores page and sort and
+//	@Description	responds with a response.CursorResponse whose next_cursor fetches
+//	@Description	the following page and stays stable while users are created or
+//	@Description	deleted.
+//	@Tags			users
+//	@Produce		json
+//	@Param			page		query		int		false	"Page number"	default(1)
+//	@Param			page_size	query		int		false	"Page size"		default(20)	maximum(100)
+//	@Param			sort		query		string	false	"Comma-separated columns among created_at, name and email, each prefixed with - for descending, e.g. -created_at,name"	default(-created_at)
+//	@Param			cursor		query		string	false	"Opaque cursor from a previous next_cursor"
+//	@Success		200			{object}	response.PaginatedResponse{data=[]models.User}
+//	@Failure		400			{object}	response.Response
+//	@Router			/users [get]
+func (h *UserHandler) List(c *gin.Context) {
+	h.crud.List(c)
+}
+
+// Export handles GET /admin/users/export
+//
+//	@Summary		Export all users
+//	@Description	Streams every user, newest first, as a JSON array or, with
+//	@Description	format=ndjson, one object per line. Users are not wrapped in
+//	@Description	the response envelope, and a failure after the first user cuts
+//	@Description	the body short instead of returning an error.
+//	@Tags			admin
+//	@Produce		json
+//	@Produce		application/x-ndjson
+//	@Security		BearerAuth
+//	@Param			format	query	string	false	"Output format"	Enums(json, ndjson)	default(json)
+//	@Success		200		{array}		models.User
+//	@Failure		400		{object}	response.Response
+//	@Failure		401		{object}	response.Response
+//	@Failure		403		{object}	response.Response
+//	@Router			/admin/users/export [get]
+func (h *UserHandler) Export(c *gin.Context) {
+	var query ExportUsersQuery
+	if !bindQuery(c, &query) {
 		return
 	}
 
-	response.NoContent(c)
+	ctx := c.Request.Context()
+	stream, err := response.StreamJSON(c, query.Format == "ndjson")
+	if err != nil {
+		// The client is already gone
+		return
+	}
+
+	err = h.service.Export(ctx, func(user *models.User) error {
+		return stream.Write(user)
+	})
+	if err != nil {
+		if !c.Writer.Written() {
+			response.Error(c, err)
+			return
+		}
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "user export failed mid-stream", "error", err)
+		}
+		return
+	}
+	_ = stream.Close()
 }
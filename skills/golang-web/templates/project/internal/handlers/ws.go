@@ -0,0 +1,38 @@
+// internal/handlers/ws.go
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/ws"
+)
+
+// WSHandler serves websocket endpoints. Echo is a sample to copy when
+// adding real-time features; hub.Broadcast reaches every client.
+type WSHandler struct {
+	hub  *ws.Hub
+	echo gin.HandlerFunc
+}
+
+// NewWSHandler creates a new WSHandler
+func NewWSHandler(hub *ws.Hub) *WSHandler {
+	return &WSHandler{
+		hub: hub,
+		echo: hub.Handler(func(conn *ws.Conn, msg ws.Message) {
+			_ = conn.Send(msg)
+		}),
+	}
+}
+
+// Echo handles GET /ws
+//
+//	@Summary		Echo websocket
+//	@Description	Upgrades to a websocket that sends every message back to its sender.
+//	@Tags			websocket
+//	@Success		101
+//	@Failure		400	{object}	response.Response
+//	@Failure		403	{string}	string	"Origin not allowed"
+//	@Failure		503	{object}	response.Response
+//	@Router			/ws [get]
+func (h *WSHandler) Echo(c *gin.Context) {
+	h.echo(c)
+}
@@ -0,0 +1,65 @@
+// internal/models/base.go
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BaseModel holds the columns every model shares: a string primary key,
+// timestamps and soft deletes. Embed it instead of redeclaring them.
+//
+// An empty ID is assigned by NewID on create. The null default makes GORM
+// leave an ID that is still empty to the database and read it back.
+type BaseModel struct {
+	ID        string    `json:"id" gorm:"primaryKey;default:null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// DeletedAt enables GORM soft deletes
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// IDGenerator returns a new primary key, or "" to leave it to the
+// database column default
+type IDGenerator func() string
+
+// ID strategies selectable with SetIDStrategy
+const (
+	IDStrategyUUID     = "uuid"     // random UUIDv4, generated in Go
+	IDStrategyUUIDv7   = "uuidv7"   // time-ordered UUIDv7, generated in Go
+	IDStrategyDatabase = "database" // column default, e.g. gen_random_uuid()
+)
+
+// NewID generates IDs for models embedding BaseModel
+var NewID IDGenerator = func() string {
+	return uuid.NewString()
+}
+
+// SetIDStrategy selects how NewID generates IDs. Call it once at startup.
+// The database strategy needs a column default on every table's id, which
+// the migrations only provide on Postgres.
+func SetIDStrategy(strategy string) error {
+	switch strategy {
+	case IDStrategyUUID, "":
+		NewID = func() string { return uuid.NewString() }
+	case IDStrategyUUIDv7:
+		NewID = func() string { return uuid.Must(uuid.NewV7()).String() }
+	case IDStrategyDatabase:
+		NewID = func() string { return "" }
+	default:
+		return fmt.Errorf("unknown id strategy: %s (supported: uuid, uuidv7, database)", strategy)
+	}
+	return nil
+}
+
+// BeforeCreate assigns an ID from NewID unless one is already set
+func (m *BaseModel) BeforeCreate(*gorm.DB) error {
+	if m.ID == "" {
+		m.ID = NewID()
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// internal/models/outbox.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes, then published by the outbox dispatcher. Events are
+// delivered at least once; consumers should deduplicate by ID.
+type OutboxEvent struct {
+	ID          string `gorm:"primaryKey;default:null"`
+	Type        string `gorm:"not null"` // e.g. "user.created"
+	AggregateID string `gorm:"not null"` // ID of the entity the event is about
+	Payload     string `gorm:"not null"` // JSON
+
+	// Attempts counts failed publishes; NextAttemptAt delays the next one
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	LastError     string
+
+	CreatedAt time.Time
+	SentAt    *time.Time
+}
+
+// TableName returns the table name for GORM
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// BeforeCreate assigns an ID from NewID and makes the event due at once
+func (e *OutboxEvent) BeforeCreate(*gorm.DB) error {
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+	if e.NextAttemptAt.IsZero() {
+		e.NextAttemptAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// internal/models/tenant.go
+package models
+
+import (
+	"context"
+
+	"github.com/yourname/myapp/pkg/ctxkeys"
+)
+
+// DefaultTenant owns every row of a single-tenant deployment, and the rows
+// written without a tenant in context, e.g. by the seed command
+const DefaultTenant = "default"
+
+// TenantFrom returns the tenant ctx is scoped to, or DefaultTenant
+func TenantFrom(ctx context.Context) string {
+	if id := ctxkeys.TenantID(ctx); id != "" {
+		return id
+	}
+	return DefaultTenant
+}
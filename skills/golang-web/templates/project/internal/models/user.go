@@ -1,16 +1,25 @@
 // internal/models/user.go
 package models
 
-import "time"
-
-// User represents a user in the system
+// User represents a user in the system. Emails are unique per tenant, and
+// the index stays unique across soft-deleted rows, so a deleted user's
+// email cannot be reused until the row is purged.
 type User struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"uniqueIndex"`
-	Name      string    `json:"name"`
-	Password  string    `json:"-"` // Never expose password
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	BaseModel
+
+	// TenantID is set from the context on create, and queries only see
+	// the users of the tenant in their context; see database.WithTenantScope
+	TenantID string `json:"tenant_id" gorm:"size:64;not null;default:default;uniqueIndex:idx_users_tenant_email,priority:1"`
+	Email    string `json:"email" gorm:"uniqueIndex:idx_users_tenant_email,priority:2"`
+	Name     string `json:"name"`
+	Password string `json:"-"` // Never expose password
+
+	// AvatarURL is where the uploaded avatar is served, empty if none
+	AvatarURL string `json:"avatar_url,omitempty"`
+
+	// Version is bumped on every save and guards against lost updates;
+	// zero means the user has not been saved yet
+	Version int `json:"version" gorm:"not null"`
 }
 
 // TableName returns the table name for GORM
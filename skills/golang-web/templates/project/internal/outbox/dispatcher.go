@@ -0,0 +1,189 @@
+// internal/outbox/dispatcher.go
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+	"github.com/yourname/myapp/pkg/retry"
+)
+
+// Publisher delivers events to a message broker such as Kafka or NATS.
+// Delivery is at least once: an event is published again if marking it
+// sent fails or the process stops in between.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// LogPublisher logs events instead of publishing them. Replace it with a
+// broker client.
+type LogPublisher struct {
+	Logger *slog.Logger
+}
+
+func (p LogPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	p.Logger.InfoContext(ctx, "outbox event",
+		"event_id", event.ID,
+		"type", event.Type,
+		"aggregate_id", event.AggregateID,
+		"payload", event.Payload,
+	)
+	return nil
+}
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithPollInterval sets how often the outbox is checked for due events
+func WithPollInterval(d time.Duration) Option {
+	return func(disp *Dispatcher) {
+		disp.interval = d
+	}
+}
+
+// WithBatchSize sets how many events are loaded per poll
+func WithBatchSize(n int) Option {
+	return func(disp *Dispatcher) {
+		disp.batchSize = n
+	}
+}
+
+// WithBackoff sets the delay before retrying a failed publish, doubling
+// per failure from initial up to max
+func WithBackoff(initial, max time.Duration) Option {
+	return func(disp *Dispatcher) {
+		disp.backoff.InitialBackoff = initial
+		disp.backoff.MaxBackoff = max
+	}
+}
+
+// Dispatcher publishes pending outbox events in the background. Failed
+// events are retried with backoff, so one failing event does not hold back
+// the others; events are therefore not strictly ordered. Running several
+// instances is safe but may publish an event more than once.
+type Dispatcher struct {
+	repo      repositories.OutboxRepository
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+	backoff   retry.Policy
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+// NewDispatcher creates a Dispatcher; call Start to begin publishing
+func NewDispatcher(repo repositories.OutboxRepository, publisher Publisher, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		repo:      repo,
+		publisher: publisher,
+		interval:  time.Second,
+		batchSize: 100,
+		backoff: retry.Policy{
+			InitialBackoff: time.Second,
+			MaxBackoff:     5 * time.Minute,
+			Multiplier:     2,
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Start polls the outbox in a new goroutine until Stop is called
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.run(ctx)
+}
+
+// Stop waits for the batch in progress to finish, or cancels it when ctx
+// expires. Events interrupted mid-publish are sent again after a restart.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil // never started
+	}
+	d.stopOnce.Do(func() { close(d.stop) })
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	defer d.cancel()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while batches come back full
+		for d.dispatch(ctx) == d.batchSize {
+			select {
+			case <-d.stop:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch publishes one batch of due events and returns how many were
+// settled, i.e. marked sent or rescheduled
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	events, err := d.repo.ListPending(ctx, time.Now(), d.batchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("failed to load outbox events", "error", err)
+		}
+		return 0
+	}
+
+	settled := 0
+	for _, event := range events {
+		if err := d.publisher.Publish(ctx, event); err != nil {
+			delay := d.backoff.Backoff(event.Attempts + 1)
+			slog.Warn("failed to publish outbox event",
+				"event_id", event.ID,
+				"type", event.Type,
+				"attempts", event.Attempts+1,
+				"retry_in", delay,
+				"error", err,
+			)
+			if err := d.repo.MarkFailed(ctx, event.ID, time.Now().Add(delay), err); err != nil {
+				slog.Error("failed to record outbox failure", "event_id", event.ID, "error", err)
+				continue
+			}
+			settled++
+			continue
+		}
+
+		if err := d.repo.MarkSent(ctx, event.ID, time.Now()); err != nil {
+			slog.Error("failed to mark outbox event sent", "event_id", event.ID, "error", err)
+			continue
+		}
+		settled++
+	}
+	return settled
+}
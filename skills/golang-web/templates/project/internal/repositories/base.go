@@ -0,0 +1,210 @@
+// internal/repositories/base.go
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/yourname/myapp/pkg/database"
+	"github.com/yourname/myapp/pkg/tracing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Base implements the queries shared by every model keyed by a string "id"
+// column. Embed it in an entity repository and add the entity's own
+// queries alongside; methods defined on the embedding type take precedence.
+type Base[T any] struct {
+	db   *gorm.DB
+	name string
+}
+
+// NewBase creates a Base for model T. name prefixes span names, e.g.
+// "UserRepository" yields "UserRepository.FindByID".
+func NewBase[T any](db *gorm.DB, name string) Base[T] {
+	return Base[T]{db: db, name: name}
+}
+
+// conn returns the connection for ctx, joining any active transaction
+func (b Base[T]) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, b.db)
+}
+
+// first loads the first row matching query, or returns nil, nil if none does
+func first[T any](db *gorm.DB, query string, args ...interface{}) (*T, error) {
+	var entity T
+	if err := db.Where(query, args...).First(&entity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (b Base[T]) FindByID(ctx context.Context, id string) (*T, error) {
+	ctx, span := tracing.Start(ctx, b.name+".FindByID")
+	defer span.End()
+
+	return first[T](b.conn(ctx), "id = ?", id)
+}
+
+// FindByIDWithDeleted is FindByID including soft-deleted rows
+func (b Base[T]) FindByIDWithDeleted(ctx context.Context, id string) (*T, error) {
+	ctx, span := tracing.Start(ctx, b.name+".FindByIDWithDeleted")
+	defer span.End()
+
+	return first[T](b.conn(ctx).Unscoped(), "id = ?", id)
+}
+
+func (b Base[T]) Save(ctx context.Context, entity *T) (*T, error) {
+	ctx, span := tracing.Start(ctx, b.name+".Save")
+	defer span.End()
+
+	if err := b.conn(ctx).Save(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// Delete soft-deletes the row if T has a gorm.DeletedAt field, and removes
+// it otherwise
+func (b Base[T]) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, b.name+".Delete")
+	defer span.End()
+
+	return b.conn(ctx).Delete(new(T), "id = ?", id).Error
+}
+
+// HardDelete removes the row for good, whether or not it was soft-deleted
+func (b Base[T]) HardDelete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, b.name+".HardDelete")
+	defer span.End()
+
+	return b.conn(ctx).Unscoped().Delete(new(T), "id = ?", id).Error
+}
+
+// Sort orders List results by Column, descending if Desc. Column is quoted
+// but not checked, so callers must pick it from a fixed set.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// List returns a page of rows ordered by each of sort in turn, newest
+// first if sort is empty, and the total row count. Remaining ties are
+// broken by id in the direction of the first column.
+func (b Base[T]) List(ctx context.Context, offset, limit int, sort []Sort) ([]*T, int64, error) {
+	ctx, span := tracing.Start(ctx, b.name+".List")
+	defer span.End()
+
+	var total int64
+	if err := b.conn(ctx).Model(new(T)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if len(sort) == 0 {
+		sort = []Sort{{Column: "created_at", Desc: true}}
+	}
+
+	query := b.conn(ctx)
+	for _, s := range sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+	}
+
+	var entities []*T
+	if err := query.
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: sort[0].Desc}).
+		Offset(offset).
+		Limit(limit).
+		Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// Each calls fn with every row, newest first, scanning one row at a time
+// so memory stays flat however many rows there are. It stops at the first
+// error from fn or once ctx ends. A connection is held until it returns,
+// so fn should not block for long.
+func (b Base[T]) Each(ctx context.Context, fn func(*T) error) error {
+	ctx, span := tracing.Start(ctx, b.name+".Each")
+	defer span.End()
+
+	db := b.conn(ctx)
+	rows, err := db.Model(new(T)).Order("created_at DESC").Order("id DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var entity T
+		if err := db.ScanRows(rows, &entity); err != nil {
+			return err
+		}
+		if err := fn(&entity); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Cursor marks a position in the (created_at, id) ordering used by
+// ListAfter. Clients only ever see its opaque encoded form.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a string produced by Cursor.Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, errors.New("cursor has no id")
+	}
+	return &c, nil
+}
+
+// ListAfter returns up to limit rows following cursor, or the first rows if
+// cursor is nil, newest first with ties broken by id. Unlike List, pages do
+// not shift when rows are inserted or deleted during iteration.
+func (b Base[T]) ListAfter(ctx context.Context, cursor *Cursor, limit int) ([]*T, error) {
+	ctx, span := tracing.Start(ctx, b.name+".ListAfter")
+	defer span.End()
+
+	q := b.conn(ctx)
+	if cursor != nil {
+		q = q.Where("created_at < ? OR (created_at = ? AND id < ?)",
+			cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var entities []*T
+	if err := q.
+		Order("created_at DESC").
+		Order("id DESC").
+		Limit(limit).
+		Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
@@ -0,0 +1,51 @@
+// internal/repositories/mocks/outbox.go
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+)
+
+var _ repositories.OutboxRepository = (*OutboxRepository)(nil)
+
+// OutboxRepository is a repositories.OutboxRepository for unit tests that
+// keeps added events in memory. AddErr, when set, fails every Add.
+type OutboxRepository struct {
+	AddErr error
+
+	mu     sync.Mutex
+	events []*models.OutboxEvent
+}
+
+// Events returns the events added so far, in order
+func (m *OutboxRepository) Events() []*models.OutboxEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.OutboxEvent(nil), m.events...)
+}
+
+func (m *OutboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	if m.AddErr != nil {
+		return m.AddErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *OutboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (m *OutboxRepository) MarkSent(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+func (m *OutboxRepository) MarkFailed(ctx context.Context, id string, nextAttemptAt time.Time, cause error) error {
+	return nil
+}
@@ -0,0 +1,12 @@
+// internal/repositories/mocks/transactor.go
+package mocks
+
+import "context"
+
+// Transactor runs fn directly, without a transaction, so nothing is rolled
+// back when fn fails. It satisfies services.Transactor.
+type Transactor struct{}
+
+func (Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
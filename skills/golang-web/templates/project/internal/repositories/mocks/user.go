@@ -0,0 +1,145 @@
+// internal/repositories/mocks/user.go
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+)
+
+var _ repositories.UserRepository = (*UserRepository)(nil)
+
+// UserRepository is a repositories.UserRepository for unit tests. Set the
+// Func field of each method the test relies on; methods left nil return
+// zero values, which the finders use to mean not found, except Save,
+// which returns the user it was given.
+type UserRepository struct {
+	FindByIDFunc               func(ctx context.Context, id string) (*models.User, error)
+	FindByIDWithDeletedFunc    func(ctx context.Context, id string) (*models.User, error)
+	FindByEmailFunc            func(ctx context.Context, email string) (*models.User, error)
+	FindByEmailWithDeletedFunc func(ctx context.Context, email string) (*models.User, error)
+	SaveFunc                   func(ctx context.Context, user *models.User) (*models.User, error)
+	DeleteFunc                 func(ctx context.Context, id string) error
+	HardDeleteFunc             func(ctx context.Context, id string) error
+	RestoreFunc                func(ctx context.Context, id string) error
+	ListFunc                   func(ctx context.Context, offset, limit int, sort []repositories.Sort) ([]*models.User, int64, error)
+	ListAfterFunc              func(ctx context.Context, cursor *repositories.Cursor, limit int) ([]*models.User, error)
+	EachFunc                   func(ctx context.Context, fn func(*models.User) error) error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// Calls returns the names of the methods called so far, in order
+func (m *UserRepository) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+// Called returns how many times method was called
+func (m *UserRepository) Called(method string) int {
+	n := 0
+	for _, c := range m.Calls() {
+		if c == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *UserRepository) record(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, method)
+}
+
+func (m *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	m.record("FindByID")
+	if m.FindByIDFunc == nil {
+		return nil, nil
+	}
+	return m.FindByIDFunc(ctx, id)
+}
+
+func (m *UserRepository) FindByIDWithDeleted(ctx context.Context, id string) (*models.User, error) {
+	m.record("FindByIDWithDeleted")
+	if m.FindByIDWithDeletedFunc == nil {
+		return nil, nil
+	}
+	return m.FindByIDWithDeletedFunc(ctx, id)
+}
+
+func (m *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.record("FindByEmail")
+	if m.FindByEmailFunc == nil {
+		return nil, nil
+	}
+	return m.FindByEmailFunc(ctx, email)
+}
+
+func (m *UserRepository) FindByEmailWithDeleted(ctx context.Context, email string) (*models.User, error) {
+	m.record("FindByEmailWithDeleted")
+	if m.FindByEmailWithDeletedFunc == nil {
+		return nil, nil
+	}
+	return m.FindByEmailWithDeletedFunc(ctx, email)
+}
+
+func (m *UserRepository) Save(ctx context.Context, user *models.User) (*models.User, error) {
+	m.record("Save")
+	if m.SaveFunc == nil {
+		return user, nil
+	}
+	return m.SaveFunc(ctx, user)
+}
+
+func (m *UserRepository) Delete(ctx context.Context, id string) error {
+	m.record("Delete")
+	if m.DeleteFunc == nil {
+		return nil
+	}
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m *UserRepository) HardDelete(ctx context.Context, id string) error {
+	m.record("HardDelete")
+	if m.HardDeleteFunc == nil {
+		return nil
+	}
+	return m.HardDeleteFunc(ctx, id)
+}
+
+func (m *UserRepository) Restore(ctx context.Context, id string) error {
+	m.record("Restore")
+	if m.RestoreFunc == nil {
+		return nil
+	}
+	return m.RestoreFunc(ctx, id)
+}
+
+func (m *UserRepository) List(ctx context.Context, offset, limit int, sort []repositories.Sort) ([]*models.User, int64, error) {
+	m.record("List")
+	if m.ListFunc == nil {
+		return nil, 0, nil
+	}
+	return m.ListFunc(ctx, offset, limit, sort)
+}
+
+func (m *UserRepository) ListAfter(ctx context.Context, cursor *repositories.Cursor, limit int) ([]*models.User, error) {
+	m.record("ListAfter")
+	if m.ListAfterFunc == nil {
+		return nil, nil
+	}
+	return m.ListAfterFunc(ctx, cursor, limit)
+}
+
+func (m *UserRepository) Each(ctx context.Context, fn func(*models.User) error) error {
+	m.record("Each")
+	if m.EachFunc == nil {
+		return nil
+	}
+	return m.EachFunc(ctx, fn)
+}
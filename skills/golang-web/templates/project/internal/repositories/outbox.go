@@ -0,0 +1,86 @@
+// internal/repositories/outbox.go
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/pkg/tracing"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// maxLastErrorLen truncates stored publish errors
+const maxLastErrorLen = 1000
+
+// OutboxRepository defines the interface for outbox event data access
+type OutboxRepository interface {
+	// Add records an event; call it with the context of the transaction
+	// making the change the event describes
+	Add(ctx context.Context, event *models.OutboxEvent) error
+	// ListPending returns up to limit unsent events due at now, oldest first
+	ListPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error)
+	MarkSent(ctx context.Context, id string, at time.Time) error
+	// MarkFailed counts a failed publish and postpones the next attempt
+	MarkFailed(ctx context.Context, id string, nextAttemptAt time.Time, cause error) error
+}
+
+type outboxRepository struct {
+	Base[models.OutboxEvent]
+}
+
+// NewOutboxRepository creates a new OutboxRepository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{Base: NewBase[models.OutboxEvent](db, "OutboxRepository")}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	ctx, span := tracing.Start(ctx, "OutboxRepository.Add")
+	defer span.End()
+
+	return r.conn(ctx).Create(event).Error
+}
+
+func (r *outboxRepository) ListPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	ctx, span := tracing.Start(ctx, "OutboxRepository.ListPending")
+	defer span.End()
+
+	// Read from the primary; a lagging replica would return sent events
+	var events []*models.OutboxEvent
+	if err := r.conn(ctx).
+		Clauses(dbresolver.Write).
+		Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+		Order("created_at").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id string, at time.Time) error {
+	ctx, span := tracing.Start(ctx, "OutboxRepository.MarkSent")
+	defer span.End()
+
+	return r.conn(ctx).
+		Model(&models.OutboxEvent{ID: id}).
+		Update("sent_at", at).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, nextAttemptAt time.Time, cause error) error {
+	ctx, span := tracing.Start(ctx, "OutboxRepository.MarkFailed")
+	defer span.End()
+
+	msg := cause.Error()
+	if len(msg) > maxLastErrorLen {
+		msg = msg[:maxLastErrorLen]
+	}
+	return r.conn(ctx).
+		Model(&models.OutboxEvent{ID: id}).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      msg,
+		}).Error
+}
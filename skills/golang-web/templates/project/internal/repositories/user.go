@@ -6,55 +6,97 @@ import (
 	"errors"
 
 	"github.com/yourname/myapp/internal/models"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/tracing"
 	"gorm.io/gorm"
 )
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	FindByID(ctx context.Context, id string) (*models.User, error)
+	FindByIDWithDeleted(ctx context.Context, id string) (*models.User, error)
+	// FindByEmail and FindByEmailWithDeleted match emails case-insensitively
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByEmailWithDeleted(ctx context.Context, email string) (*models.User, error)
+	// Save inserts a new user or updates an existing one. It returns
+	// errors.ErrUserExists if the email is taken and
+	// errors.ErrVersionConflict if the stored version no longer matches.
 	Save(ctx context.Context, user *models.User) (*models.User, error)
+	// Delete soft-deletes a user; HardDelete removes the row, which frees
+	// its email for reuse
 	Delete(ctx context.Context, id string) error
+	HardDelete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	List(ctx context.Context, offset, limit int, sort []Sort) ([]*models.User, int64, error)
+	ListAfter(ctx context.Context, cursor *Cursor, limit int) ([]*models.User, error)
+	// Each streams every user to fn without loading them all at once
+	Each(ctx context.Context, fn func(*models.User) error) error
 }
 
 type userRepository struct {
-	db *gorm.DB
+	Base[models.User]
 }
 
-// NewUserRepository creates a new UserRepository
+// NewUserRepository creates a new UserRepository. Users are scoped to the
+// tenant in the context of each call by database.WithTenantScope.
 func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepository{db: db}
+	return &userRepository{Base: NewBase[models.User](db, "UserRepository")}
 }
 
-func (r *userRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
-	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &user, nil
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.FindByEmail")
+	defer span.End()
+
+	return first[models.User](r.conn(ctx), "LOWER(email) = LOWER(?)", email)
 }
 
-func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
-	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, "email = ?", email).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &user, nil
+func (r *userRepository) FindByEmailWithDeleted(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.FindByEmailWithDeleted")
+	defer span.End()
+
+	return first[models.User](r.conn(ctx).Unscoped(), "LOWER(email) = LOWER(?)", email)
 }
 
+// Save overrides Base.Save with optimistic locking on Version
 func (r *userRepository) Save(ctx context.Context, user *models.User) (*models.User, error) {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
-		return nil, err
+	ctx, span := tracing.Start(ctx, "UserRepository.Save")
+	defer span.End()
+
+	// New users are inserted at version 1
+	if user.Version == 0 {
+		user.Version = 1
+		if err := r.conn(ctx).Create(user).Error; err != nil {
+			user.Version = 0
+			// The unique email index is the authority; pre-checks can race
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, apperrors.ErrUserExists
+			}
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Existing users are only updated if nobody saved since they were read
+	read := user.Version
+	user.Version++
+	result := r.conn(ctx).Model(user).Where("version = ?", read).Select("*").Updates(user)
+	if result.Error != nil {
+		user.Version = read
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		user.Version = read
+		return nil, apperrors.ErrVersionConflict
 	}
 	return user, nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error
+func (r *userRepository) Restore(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.Restore")
+	defer span.End()
+
+	return r.conn(ctx).Unscoped().
+		Model(&models.User{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
 }
@@ -0,0 +1,27 @@
+// internal/router/pprof.go
+package router
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pprofProfiles are the runtime profiles served by name, e.g.
+// /debug/pprof/heap or /debug/pprof/goroutine?debug=2
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// registerPprof serves net/http/pprof on g, which must be mounted at
+// /debug/pprof for the index links to resolve. CPU profiles
+// (/profile?seconds=N) must finish within the server's write timeout.
+func registerPprof(g *gin.RouterGroup) {
+	g.GET("/", gin.WrapF(pprof.Index))
+	g.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	g.GET("/profile", gin.WrapF(pprof.Profile))
+	g.GET("/symbol", gin.WrapF(pprof.Symbol))
+	g.POST("/symbol", gin.WrapF(pprof.Symbol))
+	g.GET("/trace", gin.WrapF(pprof.Trace))
+	for _, name := range pprofProfiles {
+		g.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+}
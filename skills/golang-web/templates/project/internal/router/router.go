@@ -2,41 +2,318 @@
 package router
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/docs"
 	"github.com/yourname/myapp/internal/handlers"
+	"github.com/yourname/myapp/pkg/health"
+	"github.com/yourname/myapp/pkg/middleware"
+	"github.com/yourname/myapp/pkg/response"
+	"github.com/yourname/myapp/pkg/ws"
 )
 
-// Setup configures and returns the router
-func Setup(cfg *configs.Config, userHandler *handlers.UserHandler) *gin.Engine {
+// readinessTimeout bounds how long a single /readyz check may take
+const readinessTimeout = 2 * time.Second
+
+// swaggerCSP is the Content-Security-Policy the Swagger UI needs
+const swaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+
+// maintenancePath serves the maintenance toggle, which stays reachable
+// while in maintenance
+const maintenancePath = "/admin/maintenance"
+
+// logLevelPath serves the log level, which also stays reachable while in
+// maintenance so an incident can be debugged
+const logLevelPath = "/admin/log-level"
+
+// Setup configures and returns the router. /health reports checks and
+// /readyz reports probe. While maintenance is set the API answers 503;
+// health probes keep working. level is the minimum log level, adjustable
+// by admins. limiter backs rate limiting when it is enabled and hub tracks
+// websocket connections. Every route is served under cfg.Server.BasePath.
+func Setup(cfg *configs.Config, probe *health.Probe, checks *health.Registry, maintenance *atomic.Bool, level *slog.LevelVar, limiter middleware.Limiter, hub *ws.Hub, userHandler *handlers.UserHandler, authHandler *handlers.AuthHandler) (*gin.Engine, error) {
 	// Set Gin mode
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Report validation errors using request field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+		if err := v.RegisterValidation("maxbytes", maxBytes); err != nil {
+			return nil, fmt.Errorf("register maxbytes validation: %w", err)
+		}
+	}
+
+	// Echoing any origin with credentials would let every site act as the
+	// signed-in user
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		return nil, errors.New("cors.allow_credentials cannot be combined with the * origin; list the origins instead")
+	}
+
 	r := gin.New()
+	base := basePath(cfg.Server.BasePath)
+
+	// Gin trusts every proxy by default, letting any client spoof its IP
+	if err := r.SetTrustedProxies(trustedProxies(cfg.Server)); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	// Middleware
-	r.Use(gin.Recovery())
-	r.Use(gin.Logger())
+	r.Use(middleware.RequestID())
+	if cfg.Tracing.Enabled {
+		r.Use(middleware.Tracing())
+	}
+	r.Use(middleware.Recovery(slog.Default()))
+	r.Use(middleware.SlogLogger(slog.Default(), logOptions(base, cfg.Log)...))
+	// Server-Timing breaks responses down by phase, for debugging only
+	r.Use(middleware.Timing(middleware.WithServerTiming(cfg.Server.Mode == "debug")))
+	// Metrics endpoint is registered before CORS and rate limiting so
+	// scrapes are never throttled
+	if cfg.Metrics.Enabled {
+		r.Use(middleware.Metrics())
+		r.GET(base+cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
+	}
+	if cfg.SecureHeaders.Enabled {
+		r.Use(middleware.SecureHeaders(cfg.SecureHeaders))
+	}
+	r.Use(middleware.CORS(cfg.CORS))
+	r.Use(middleware.Maintenance(maintenance,
+		middleware.WithMaintenanceRetryAfter(cfg.Maintenance.RetryAfter),
+		middleware.WithMaintenanceExempt(prefixPaths(base, []string{"/health", "/livez", "/readyz", maintenancePath, logLevelPath})...),
+	))
+	if cfg.RateLimit.Enabled {
+		r.Use(middleware.RateLimit(limiter))
+	}
+	if cfg.Compression.Enabled {
+		r.Use(middleware.Compress(
+			middleware.WithCompressionLevel(cfg.Compression.Level),
+			middleware.WithMinCompressSize(cfg.Compression.MinSize),
+		))
+	}
+	// Body logging exposes payloads, so it never runs in release mode
+	if cfg.Log.Bodies.Enabled {
+		if cfg.Server.Mode == "debug" {
+			r.Use(middleware.DebugBodyLog(slog.Default(),
+				middleware.WithBodyLogMaxSize(cfg.Log.Bodies.MaxSize),
+				middleware.WithBodyLogRedact(cfg.Log.Bodies.Redact...),
+			))
+		} else {
+			slog.Warn("body logging is only available in debug mode", "mode", cfg.Server.Mode)
+		}
+	}
+
+	// Routes are served under the base path. The group copies the
+	// middleware registered so far, so it must be created after it.
+	root := r.Group(base)
+
+	// Dependency health, 503 when a critical dependency is down
+	root.GET("/health", func(c *gin.Context) {
+		report := checks.Check(c.Request.Context())
+		status := http.StatusOK
+		if report.Status == health.StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		response.JSON(c, status, report)
+	})
+
+	// Kubernetes probes
+	root.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	root.GET("/readyz", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		if err := probe.Ready(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// API documentation. The UI runs inline scripts and styles, which the
+	// API's default CSP forbids. The spec's base path follows ours so "Try
+	// it out" requests reach the API.
+	docs.SwaggerInfo.BasePath = base + "/api/v1"
+	root.GET("/swagger/*any", func(c *gin.Context) {
+		if cfg.SecureHeaders.Enabled && cfg.SecureHeaders.ContentSecurityPolicy != "" {
+			c.Header("Content-Security-Policy", swaggerCSP)
+		}
+	}, ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Files kept by the local storage backend, unless its URL points
+	// elsewhere. S3 objects are served by the bucket.
+	if cfg.Storage.Backend == configs.StorageLocal && strings.HasPrefix(cfg.Storage.Local.URL, "/") {
+		r.Static(cfg.Storage.Local.URL, cfg.Storage.Local.Dir)
+	}
+
+	auth := middleware.Auth(cfg.Auth)
+
+	// Tenant runs ahead of Auth, which then rejects tokens of other tenants
+	var tenant []gin.HandlerFunc
+	if cfg.Tenancy.Enabled {
+		tenant = append(tenant, middleware.Tenant(tenantSources(cfg.Tenancy, cfg.Auth)...))
+	}
+
+	// Profiling, opt-in and admin only
+	if cfg.Pprof.Enabled {
+		registerPprof(root.Group("/debug/pprof", auth, middleware.RequireRole("admin")))
+	}
+
+	// Maintenance mode toggle and log level
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenance)
+	logLevelHandler := handlers.NewLogLevelHandler(level)
+	admin := root.Group("", append(tenant, auth, middleware.RequireRole("admin"))...)
+	admin.GET(maintenancePath, maintenanceHandler.Get)
+	admin.PUT(maintenancePath, maintenanceHandler.Set)
+	admin.GET(logLevelPath, logLevelHandler.Get)
+	admin.PUT(logLevelPath, logLevelHandler.Set)
+	admin.GET("/admin/users/export", userHandler.Export)
+
 	// API v1
-	v1 := r.Group("/api/v1")
+	idempotencyStore := middleware.NewMemoryIdempotencyStore(cfg.Idempotency.TTL)
+	v1 := root.Group("/api/v1")
+	v1.Use(middleware.Timeout(cfg.Server.RequestTimeout))
+	v1.Use(middleware.BodyLimit(cfg.Server.BodyLimit.Default))
+	// Header versions refine the path version; list each one handlers
+	// branch on, e.g. APIVersion(1, 2)
+	v1.Use(middleware.APIVersion(1))
+	v1.Use(tenant...)
 	{
+		v1.POST("/auth/login", authHandler.Login)
+
 		// Users
 		users := v1.Group("/users")
 		{
-			users.POST("", userHandler.Create)
-			users.GET("/:id", userHandler.Get)
-			users.PUT("/:id", userHandler.Update)
-			users.DELETE("/:id", userHandler.Delete)
+			// The service restricts writes to the user themselves or an
+			// admin. Its owner check replaces the admin-only DELETE: users
+			// may delete their own account.
+			handlers.RegisterCRUD(users, userHandler, handlers.CRUDMiddleware{
+				Create: []gin.HandlerFunc{middleware.Idempotency(idempotencyStore)},
+				Update: []gin.HandlerFunc{auth},
+				Delete: []gin.HandlerFunc{auth},
+			})
+			// Bulk creation is for admins; each user costs a bcrypt hash
+			users.POST("/batch",
+				auth,
+				middleware.RequireRole("admin"),
+				middleware.BodyLimit(cfg.Server.BodyLimit.Batch),
+				middleware.Idempotency(idempotencyStore),
+				userHandler.CreateBatch,
+			)
+			users.PATCH("/:id", auth, userHandler.Patch)
+			users.PUT("/:id/avatar", auth, middleware.BodyLimit(cfg.Server.BodyLimit.Upload), userHandler.Avatar)
+		}
+
+		// Websocket sample; the connection outlives the request timeout
+		if cfg.WebSocket.Enabled {
+			v1.GET("/ws", handlers.NewWSHandler(hub).Echo)
 		}
 	}
 
-	return r
+	return r, nil
+}
+
+// basePath normalizes the configured base path to "" or "/prefix"
+func basePath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// prefixPaths returns paths with base prepended
+func prefixPaths(base string, paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = base + p
+	}
+	return out
+}
+
+// logOptions configures the request log, with paths relative to base
+func logOptions(base string, cfg configs.LogConfig) []middleware.LoggerOption {
+	opts := []middleware.LoggerOption{
+		middleware.WithSkipPaths(prefixPaths(base, cfg.SkipPaths)...),
+		middleware.WithSampleRate(cfg.Sampling.Rate),
+		middleware.WithSlowThreshold(cfg.Sampling.SlowThreshold),
+	}
+	// Config keys arrive lowercased; the method's case does not matter
+	for route, rate := range cfg.Sampling.Routes {
+		method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok {
+			method, path = "", method
+		}
+		opts = append(opts, middleware.WithRouteSampleRate(method, base+strings.TrimSpace(path), rate))
+	}
+	return opts
+}
+
+// tenantSources lists where Tenant looks for the tenant, in config order
+func tenantSources(cfg configs.TenancyConfig, authCfg configs.AuthConfig) []middleware.TenantOption {
+	var opts []middleware.TenantOption
+	if cfg.Header != "" {
+		opts = append(opts, middleware.WithTenantHeader(cfg.Header))
+	}
+	if cfg.Domain != "" {
+		opts = append(opts, middleware.WithTenantSubdomain(cfg.Domain))
+	}
+	if cfg.Claim {
+		opts = append(opts, middleware.WithTenantClaim(authCfg))
+	}
+	return opts
+}
+
+// trustedProxies returns the configured proxies, or loopback in debug mode
+// so a local reverse proxy works out of the box
+func trustedProxies(cfg configs.ServerConfig) []string {
+	if len(cfg.TrustedProxies) > 0 || cfg.Mode == "release" {
+		return cfg.TrustedProxies
+	}
+	return []string{"127.0.0.1", "::1"}
+}
+
+// maxBytes implements the maxbytes=N tag, which limits the length of a
+// string in bytes where max counts runes, e.g. for the 72 bytes bcrypt
+// reads of a password
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("maxbytes: invalid parameter %q", fl.Param()))
+	}
+	return len(fl.Field().String()) <= limit
+}
+
+// requestFieldName returns the json or form name of a struct field
+func requestFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			break
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
 }
@@ -0,0 +1,667 @@
+// internal/router/router_test.go
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/internal/handlers"
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+	"github.com/yourname/myapp/internal/repositories/mocks"
+	"github.com/yourname/myapp/internal/services"
+	"github.com/yourname/myapp/pkg/health"
+	"github.com/yourname/myapp/pkg/lockout"
+	"github.com/yourname/myapp/pkg/middleware"
+	"github.com/yourname/myapp/pkg/storage"
+	"github.com/yourname/myapp/pkg/worker"
+	"github.com/yourname/myapp/pkg/ws"
+)
+
+const testJWTSecret = "test-secret"
+
+// testServer is the full router backed by an in-memory user repository
+type testServer struct {
+	t      *testing.T
+	engine *gin.Engine
+	repo   *mocks.UserRepository
+}
+
+// envelope is the response.Response body every endpoint replies with
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Details json.RawMessage `json:"details"`
+}
+
+// newTestServer sets up the router, applying configure to the config first
+func newTestServer(t *testing.T, configure ...func(*configs.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &configs.Config{}
+	cfg.Server.RequestTimeout = 5 * time.Second
+	cfg.Server.BodyLimit.Default = 1 << 20
+	cfg.Server.BodyLimit.Batch = 1 << 20
+	cfg.Idempotency.TTL = time.Minute
+	cfg.Auth.JWTSecret = testJWTSecret
+	cfg.Auth.TokenTTL = time.Hour
+	cfg.Server.BodyLimit.Upload = 1 << 20
+	cfg.Storage.Backend = configs.StorageLocal
+	cfg.Storage.Local.Dir = t.TempDir()
+	cfg.Storage.Local.URL = "/uploads"
+	cfg.Storage.AvatarMaxSize = 1 << 10
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	var guard *lockout.Guard
+	if lc := cfg.Auth.Lockout; lc.Enabled {
+		guard = lockout.New(lockout.NewMemory(), lockout.Policy{MaxFailures: lc.MaxFailures, Window: lc.Window, Duration: lc.Duration})
+	}
+
+	store, err := storage.NewLocal(cfg.Storage.Local.Dir, cfg.Storage.Local.URL)
+	if err != nil {
+		t.Fatalf("set up storage: %v", err)
+	}
+
+	jobs := worker.New()
+	t.Cleanup(func() { _ = jobs.Stop(context.Background()) })
+
+	repo := newMemoryUserRepository()
+	svc := services.NewUserService(repo, &mocks.OutboxRepository{}, mocks.Transactor{})
+	engine, err := Setup(cfg, health.NewProbe(), health.NewRegistry(), new(atomic.Bool), new(slog.LevelVar), middleware.NewMemoryLimiter(10, 20), ws.NewHub(),
+		handlers.NewUserHandler(svc, store, jobs, cfg.Storage.AvatarMaxSize), handlers.NewAuthHandler(svc, guard, cfg.Auth))
+	if err != nil {
+		t.Fatalf("set up router: %v", err)
+	}
+
+	return &testServer{t: t, engine: engine, repo: repo}
+}
+
+// newMemoryUserRepository stubs the repository with a map, enough for the
+// service to behave as it would against a database, tenant scoping included
+func newMemoryUserRepository() *mocks.UserRepository {
+	var mu sync.Mutex
+	users := make(map[string]models.User)
+	find := func(ctx context.Context, match func(models.User) bool) *models.User {
+		for _, u := range users {
+			if u.TenantID == models.TenantFrom(ctx) && match(u) {
+				return &u
+			}
+		}
+		return nil
+	}
+
+	return &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return find(ctx, func(u models.User) bool { return u.ID == id }), nil
+		},
+		FindByEmailFunc: func(ctx context.Context, email string) (*models.User, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return find(ctx, func(u models.User) bool { return strings.EqualFold(u.Email, email) }), nil
+		},
+		FindByEmailWithDeletedFunc: func(ctx context.Context, email string) (*models.User, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return find(ctx, func(u models.User) bool { return strings.EqualFold(u.Email, email) }), nil
+		},
+		SaveFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if user.ID == "" {
+				user.ID = models.NewID()
+				user.TenantID = models.TenantFrom(ctx)
+				user.CreatedAt = time.Now()
+			}
+			user.Version++
+			users[user.ID] = *user
+			return user, nil
+		},
+		DeleteFunc: func(ctx context.Context, id string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(users, id)
+			return nil
+		},
+		EachFunc: func(ctx context.Context, fn func(*models.User) error) error {
+			mu.Lock()
+			all := make([]models.User, 0, len(users))
+			for _, u := range users {
+				if u.TenantID == models.TenantFrom(ctx) {
+					all = append(all, u)
+				}
+			}
+			mu.Unlock()
+			for i := range all {
+				if err := fn(&all[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ListFunc: func(ctx context.Context, offset, limit int, _ []repositories.Sort) ([]*models.User, int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var page []*models.User
+			for _, u := range users {
+				if u.TenantID == models.TenantFrom(ctx) {
+					u := u
+					page = append(page, &u)
+				}
+			}
+			return page, int64(len(page)), nil
+		},
+	}
+}
+
+// performRequest sends a request with an optional JSON body through the
+// router and returns the recorded response. Headers are given as
+// name, value pairs.
+func (s *testServer) performRequest(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+// bearer returns an Authorization header pair for a token issued to sub
+func (s *testServer) bearer(sub string, roles ...string) []string {
+	s.t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		s.t.Fatalf("sign token: %v", err)
+	}
+	return []string{"Authorization", "Bearer " + token}
+}
+
+// createUser creates a user through the API and returns it
+func (s *testServer) createUser(email string) models.User {
+	s.t.Helper()
+
+	w := s.performRequest(http.MethodPost, "/api/v1/users",
+		`{"email":"`+email+`","name":"Test User","password":"password123"}`)
+	var user models.User
+	decodeEnvelope(s.t, w, http.StatusCreated, &user)
+	return user
+}
+
+// decodeEnvelope checks the status and envelope of w and decodes its data
+// into data, if not nil
+func decodeEnvelope(t *testing.T, w *httptest.ResponseRecorder, status int, data interface{}) envelope {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+	var env envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode envelope: %v; body: %s", err, w.Body.String())
+	}
+	if data != nil {
+		if err := json.Unmarshal(env.Data, data); err != nil {
+			t.Fatalf("decode data: %v; body: %s", err, w.Body.String())
+		}
+	}
+	return env
+}
+
+func TestUserCRUD(t *testing.T) {
+	s := newTestServer(t)
+
+	created := s.createUser("foo@bar.com")
+	if created.ID == "" || created.Email != "foo@bar.com" || created.Name != "Test User" {
+		t.Fatalf("created user = %+v", created)
+	}
+
+	var got models.User
+	env := decodeEnvelope(t, s.performRequest(http.MethodGet, "/api/v1/users/"+created.ID, ""), http.StatusOK, &got)
+	if env.Code != 0 || env.Message != "success" {
+		t.Errorf("envelope = %+v, want code 0 and message success", env)
+	}
+	if got.ID != created.ID {
+		t.Errorf("got user %q, want %q", got.ID, created.ID)
+	}
+
+	var list []models.User
+	decodeEnvelope(t, s.performRequest(http.MethodGet, "/api/v1/users", ""), http.StatusOK, &list)
+	if len(list) != 1 {
+		t.Errorf("listed %d users, want 1", len(list))
+	}
+
+	var updated models.User
+	decodeEnvelope(t, s.performRequest(http.MethodPut, "/api/v1/users/"+created.ID, `{"name":"Renamed"}`,
+		s.bearer(created.ID)...), http.StatusOK, &updated)
+	if updated.Name != "Renamed" {
+		t.Errorf("updated name = %q, want %q", updated.Name, "Renamed")
+	}
+
+	var patched models.User
+	decodeEnvelope(t, s.performRequest(http.MethodPatch, "/api/v1/users/"+created.ID, `{"name":null}`,
+		s.bearer(created.ID)...), http.StatusOK, &patched)
+	if patched.Name != "Renamed" || patched.Version != updated.Version+1 {
+		t.Errorf("patched user = %+v, want name %q kept at version %d", patched, "Renamed", updated.Version+1)
+	}
+
+	w := s.performRequest(http.MethodDelete, "/api/v1/users/"+created.ID, "", s.bearer(created.ID)...)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want %d; body: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	decodeEnvelope(t, s.performRequest(http.MethodGet, "/api/v1/users/"+created.ID, ""), http.StatusNotFound, nil)
+}
+
+func TestUserErrorMapping(t *testing.T) {
+	s := newTestServer(t)
+	existing := s.createUser("taken@bar.com")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		headers []string
+		status  int
+		message string
+	}{
+		{
+			name:   "get missing user",
+			method: http.MethodGet, path: "/api/v1/users/missing",
+			status: http.StatusNotFound, message: "user not found",
+		},
+		{
+			name:   "update missing user",
+			method: http.MethodPut, path: "/api/v1/users/missing", body: `{"name":"Foo"}`,
+			headers: s.bearer("admin", "admin"),
+			status:  http.StatusNotFound, message: "user not found",
+		},
+		{
+			name:   "delete missing user",
+			method: http.MethodDelete, path: "/api/v1/users/missing",
+			headers: s.bearer("admin", "admin"),
+			status:  http.StatusNotFound, message: "user not found",
+		},
+		{
+			name:   "duplicate email",
+			method: http.MethodPost, path: "/api/v1/users",
+			body:   `{"email":"Taken@Bar.com","name":"Other","password":"password123"}`,
+			status: http.StatusConflict, message: "user already exists",
+		},
+		{
+			name:   "invalid body",
+			method: http.MethodPost, path: "/api/v1/users", body: `{"email":"nope"}`,
+			status: http.StatusBadRequest, message: "invalid parameters",
+		},
+		{
+			// 40 runes, but 80 bytes, past what bcrypt reads
+			name:   "password over 72 bytes",
+			method: http.MethodPost, path: "/api/v1/users",
+			body:   `{"email":"long@bar.com","name":"Long","password":"` + strings.Repeat("é", 40) + `"}`,
+			status: http.StatusBadRequest, message: "invalid parameters",
+		},
+		{
+			name:   "replace without name",
+			method: http.MethodPut, path: "/api/v1/users/" + existing.ID, body: `{"version":1}`,
+			headers: s.bearer(existing.ID),
+			status:  http.StatusBadRequest, message: "invalid parameters",
+		},
+		{
+			name:   "patch with short name",
+			method: http.MethodPatch, path: "/api/v1/users/" + existing.ID, body: `{"name":" a "}`,
+			headers: s.bearer(existing.ID),
+			status:  http.StatusBadRequest, message: "invalid parameters",
+		},
+		{
+			name:   "unknown sort column",
+			method: http.MethodGet, path: "/api/v1/users?sort=-password",
+			status: http.StatusBadRequest, message: "invalid parameters",
+		},
+		{
+			name:   "hard delete by non-admin",
+			method: http.MethodDelete, path: "/api/v1/users/" + existing.ID + "?hard=true",
+			headers: s.bearer(existing.ID),
+			status:  http.StatusForbidden, message: "forbidden",
+		},
+		{
+			name:   "update without token",
+			method: http.MethodPut, path: "/api/v1/users/" + existing.ID, body: `{"name":"Foo"}`,
+			status: http.StatusUnauthorized, message: "unauthorized",
+		},
+		{
+			name:   "update someone else",
+			method: http.MethodPut, path: "/api/v1/users/" + existing.ID, body: `{"name":"Foo"}`,
+			headers: s.bearer("someone-else"),
+			status:  http.StatusForbidden, message: "forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := decodeEnvelope(t, s.performRequest(tt.method, tt.path, tt.body, tt.headers...), tt.status, nil)
+			if env.Code != tt.status || env.Message != tt.message {
+				t.Errorf("envelope = {code: %d, message: %q}, want {code: %d, message: %q}",
+					env.Code, env.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestSetupRejectsCredentialedWildcardCORS(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.CORS = configs.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+	if _, err := Setup(cfg, nil, nil, nil, nil, nil, nil, nil, nil); err == nil {
+		t.Error("Setup accepted credentials with the * origin")
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Server.BasePath = "/myapp/"
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/myapp/health", http.StatusOK},
+		{"/myapp/livez", http.StatusOK},
+		{"/myapp/api/v1/users", http.StatusOK},
+		{"/health", http.StatusNotFound},
+		{"/api/v1/users", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := s.performRequest(http.MethodGet, tt.path, "")
+		if w.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, tt.status)
+		}
+		// Global middleware still runs for prefixed routes
+		if w.Code == http.StatusOK && w.Header().Get(middleware.RequestIDHeader) == "" {
+			t.Errorf("GET %s has no %s header", tt.path, middleware.RequestIDHeader)
+		}
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Auth.Lockout = configs.LockoutConfig{Enabled: true, MaxFailures: 3, Window: time.Minute, Duration: time.Minute}
+	})
+	user := s.createUser("foo@bar.com")
+
+	login := func(password string) *httptest.ResponseRecorder {
+		return s.performRequest(http.MethodPost, "/api/v1/auth/login",
+			`{"email":"foo@bar.com","password":"`+password+`"}`)
+	}
+
+	var result handlers.LoginResult
+	decodeEnvelope(t, login("password123"), http.StatusOK, &result)
+	w := s.performRequest(http.MethodPatch, "/api/v1/users/"+user.ID, `{"name":"Renamed"}`,
+		"Authorization", "Bearer "+result.Token)
+	decodeEnvelope(t, w, http.StatusOK, nil)
+
+	decodeEnvelope(t, login("wrong-password"), http.StatusUnauthorized, nil)
+	decodeEnvelope(t, login("wrong-password"), http.StatusUnauthorized, nil)
+	w = login("wrong-password")
+	decodeEnvelope(t, w, http.StatusForbidden, nil)
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", w.Header().Get("Retry-After"))
+	}
+
+	// Locked even with the right password
+	w = login("password123")
+	decodeEnvelope(t, w, http.StatusForbidden, nil)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("locked login has no Retry-After")
+	}
+}
+
+func TestLoginSuccessKeepsClientFailures(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Auth.Lockout = configs.LockoutConfig{Enabled: true, MaxFailures: 3, Window: time.Minute, Duration: time.Minute}
+	})
+	s.createUser("own@bar.com")
+	login := func(email, password string) *httptest.ResponseRecorder {
+		return s.performRequest(http.MethodPost, "/api/v1/auth/login",
+			`{"email":"`+email+`","password":"`+password+`"}`)
+	}
+
+	decodeEnvelope(t, login("a@bar.com", "guess"), http.StatusUnauthorized, nil)
+	decodeEnvelope(t, login("b@bar.com", "guess"), http.StatusUnauthorized, nil)
+	// Logging into its own account does not clear the client's count
+	decodeEnvelope(t, login("own@bar.com", "password123"), http.StatusOK, nil)
+	decodeEnvelope(t, login("c@bar.com", "guess"), http.StatusForbidden, nil)
+}
+
+func TestIdempotency(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Tenancy = configs.TenancyConfig{Enabled: true, Header: middleware.TenantHeader}
+	})
+	create := func(tenant, email string) *httptest.ResponseRecorder {
+		return s.performRequest(http.MethodPost, "/api/v1/users",
+			`{"email":"`+email+`","name":"Foo","password":"password123"}`,
+			middleware.IdempotencyKeyHeader, "key-1", middleware.TenantHeader, tenant)
+	}
+
+	var first, replayed models.User
+	decodeEnvelope(t, create("acme", "foo@bar.com"), http.StatusCreated, &first)
+	w := create("acme", "foo@bar.com")
+	decodeEnvelope(t, w, http.StatusCreated, &replayed)
+	if w.Header().Get("Idempotent-Replayed") != "true" || replayed.ID != first.ID {
+		t.Errorf("repeated request was not replayed: %s", w.Body.String())
+	}
+
+	decodeEnvelope(t, create("acme", "other@bar.com"), http.StatusUnprocessableEntity, nil)
+
+	// Another tenant's key is its own, so the new body is no reuse
+	w = create("globex", "other@bar.com")
+	decodeEnvelope(t, w, http.StatusCreated, &replayed)
+	if w.Header().Get("Idempotent-Replayed") != "" || replayed.ID == first.ID {
+		t.Errorf("another tenant got a replayed response: %s", w.Body.String())
+	}
+}
+
+func TestCreateBatchRequiresAdmin(t *testing.T) {
+	s := newTestServer(t)
+	body := `{"users":[{"email":"a@bar.com","name":"Foo","password":"password123"}]}`
+
+	decodeEnvelope(t, s.performRequest(http.MethodPost, "/api/v1/users/batch", body), http.StatusUnauthorized, nil)
+	decodeEnvelope(t, s.performRequest(http.MethodPost, "/api/v1/users/batch", body, s.bearer("user-id")...), http.StatusForbidden, nil)
+	decodeEnvelope(t, s.performRequest(http.MethodPost, "/api/v1/users/batch", body, s.bearer("admin-id", "admin")...), http.StatusCreated, nil)
+}
+
+func TestTenantResolution(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Tenancy = configs.TenancyConfig{Enabled: true, Header: middleware.TenantHeader, Domain: "example.com", Claim: true}
+	})
+
+	w := s.performRequest(http.MethodPost, "/api/v1/users",
+		`{"email":"foo@bar.com","name":"Foo","password":"password123"}`, middleware.TenantHeader, "acme")
+	var user models.User
+	decodeEnvelope(t, w, http.StatusCreated, &user)
+	if user.TenantID != "acme" {
+		t.Errorf("tenant_id = %q, want acme", user.TenantID)
+	}
+
+	// Requests naming no tenant are refused rather than given a default
+	login := `{"email":"foo@bar.com","password":"password123"}`
+	w = s.performRequest(http.MethodPost, "http://example.com/api/v1/auth/login", login)
+	decodeEnvelope(t, w, http.StatusBadRequest, nil)
+
+	w = s.performRequest(http.MethodPost, "http://ACME.example.com:8080/api/v1/auth/login", login)
+	var result handlers.LoginResult
+	decodeEnvelope(t, w, http.StatusOK, &result)
+
+	patch := func(headers ...string) *httptest.ResponseRecorder {
+		headers = append(headers, "Authorization", "Bearer "+result.Token)
+		return s.performRequest(http.MethodPatch, "/api/v1/users/"+user.ID, `{"name":"Renamed"}`, headers...)
+	}
+	// The token names its own tenant, and is worthless in any other
+	decodeEnvelope(t, patch(), http.StatusOK, nil)
+	decodeEnvelope(t, patch(middleware.TenantHeader, "acme"), http.StatusOK, nil)
+	decodeEnvelope(t, patch(middleware.TenantHeader, "globex"), http.StatusUnauthorized, nil)
+
+	decodeEnvelope(t, patch(middleware.TenantHeader, "Not_A_Tenant"), http.StatusBadRequest, nil)
+}
+
+func TestUserExport(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("a@bar.com")
+	s.createUser("b@bar.com")
+	admin := s.bearer("admin-id", "admin")
+
+	w := s.performRequest(http.MethodGet, "/admin/users/export", "", admin...)
+	var users []models.User
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("export = %d %s: %v", w.Code, w.Body.String(), err)
+	}
+	if len(users) != 2 {
+		t.Errorf("exported %d users, want 2", len(users))
+	}
+
+	w = s.performRequest(http.MethodGet, "/admin/users/export?format=ndjson", "", admin...)
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+	if lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("ndjson export has %d lines, want 2: %q", len(lines), w.Body.String())
+	}
+
+	w = s.performRequest(http.MethodGet, "/admin/users/export", "", s.bearer("user-id")...)
+	decodeEnvelope(t, w, http.StatusForbidden, nil)
+}
+
+func TestUserExportIsTenantScoped(t *testing.T) {
+	s := newTestServer(t, func(cfg *configs.Config) {
+		cfg.Tenancy = configs.TenancyConfig{Enabled: true, Header: middleware.TenantHeader, Claim: true}
+	})
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		w := s.performRequest(http.MethodPost, "/api/v1/users",
+			`{"email":"`+models.NewID()+`@bar.com","name":"Foo","password":"password123"}`, middleware.TenantHeader, tenant)
+		decodeEnvelope(t, w, http.StatusCreated, nil)
+	}
+	token, _, err := middleware.NewToken(configs.AuthConfig{JWTSecret: testJWTSecret}, "admin-id", "acme", []string{"admin"}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewToken: %v", err)
+	}
+	admin := []string{"Authorization", "Bearer " + token}
+
+	// The token's tenant is the export's
+	w := s.performRequest(http.MethodGet, "/admin/users/export", "", admin...)
+	var users []models.User
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("export = %d %s: %v", w.Code, w.Body.String(), err)
+	}
+	if len(users) != 2 || users[0].TenantID != "acme" {
+		t.Errorf("exported %d users, want acme's 2", len(users))
+	}
+
+	w = s.performRequest(http.MethodGet, "/admin/users/export", "", append(admin, middleware.TenantHeader, "globex")...)
+	decodeEnvelope(t, w, http.StatusUnauthorized, nil)
+}
+
+// uploadAvatar puts content as id's avatar, authenticated as caller
+func (s *testServer) uploadAvatar(id, caller string, content []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		s.t.Fatalf("create form file: %v", err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+id+"/avatar", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	auth := s.bearer(caller)
+	req.Header.Set(auth[0], auth[1])
+
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserAvatar(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.createUser("owner@bar.com")
+	other := s.createUser("other@bar.com")
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+
+	digest := sha256.Sum256(png)
+	pngURL := "/uploads/avatars/" + owner.ID + "/" + hex.EncodeToString(digest[:8])
+
+	var user models.User
+	decodeEnvelope(t, s.uploadAvatar(owner.ID, owner.ID, png), http.StatusOK, &user)
+	if user.AvatarURL != pngURL {
+		t.Fatalf("avatar_url = %q, want %q", user.AvatarURL, pngURL)
+	}
+	w := s.performRequest(http.MethodGet, user.AvatarURL, "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), png) {
+		t.Errorf("GET avatar = %d, %d bytes; want 200 and the upload", w.Code, w.Body.Len())
+	}
+
+	// The same image keeps its URL; another gets a new one and the old
+	// object goes
+	decodeEnvelope(t, s.uploadAvatar(owner.ID, owner.ID, png), http.StatusOK, &user)
+	if user.AvatarURL != pngURL {
+		t.Errorf("avatar_url after uploading the same image = %q, want %q", user.AvatarURL, pngURL)
+	}
+	if w := s.performRequest(http.MethodGet, pngURL, ""); w.Code != http.StatusOK {
+		t.Errorf("GET avatar after uploading the same image = %d, want 200", w.Code)
+	}
+	decodeEnvelope(t, s.uploadAvatar(owner.ID, owner.ID, append(png, 1)), http.StatusOK, &user)
+	if user.AvatarURL == pngURL {
+		t.Error("avatar_url unchanged after uploading another image")
+	}
+	// The old object is deleted in the background
+	deadline := time.Now().Add(time.Second)
+	for s.performRequest(http.MethodGet, pngURL, "").Code != http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the replaced avatar to be deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	decodeEnvelope(t, s.uploadAvatar(owner.ID, owner.ID, []byte("plain text")), http.StatusUnsupportedMediaType, nil)
+	decodeEnvelope(t, s.uploadAvatar(owner.ID, owner.ID, append(png, make([]byte, 1<<10)...)), http.StatusRequestEntityTooLarge, nil)
+
+	// Refused before anything is stored
+	decodeEnvelope(t, s.uploadAvatar(other.ID, owner.ID, png), http.StatusForbidden, nil)
+	if w := s.performRequest(http.MethodGet, "/uploads/avatars/"+other.ID+"/"+hex.EncodeToString(digest[:8]), ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET forbidden avatar = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,48 @@
+// internal/services/events.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/pkg/errors"
+)
+
+// Event types written to the outbox
+const (
+	EventUserCreated = "user.created"
+)
+
+// UserCreatedEvent is the payload of EventUserCreated
+type UserCreatedEvent struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// emitUserCreated records EventUserCreated for user. Call it inside the
+// transaction that saves the user so the event is stored only if the
+// user is.
+func (s *userService) emitUserCreated(ctx context.Context, user *models.User) error {
+	payload, err := json.Marshal(UserCreatedEvent{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.KindInternal, "failed to encode event")
+	}
+
+	if err := s.outbox.Add(ctx, &models.OutboxEvent{
+		Type:        EventUserCreated,
+		AggregateID: user.ID,
+		Payload:     string(payload),
+	}); err != nil {
+		return errors.Wrap(err, errors.KindInternal, "failed to record event")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// internal/services/lookup.go
+package services
+
+import (
+	"context"
+
+	"github.com/yourname/myapp/pkg/database"
+	"golang.org/x/sync/singleflight"
+)
+
+// lookupGroup coalesces concurrent identical lookups, so a burst of reads
+// of one hot key costs a single query. Results are shared only with the
+// callers already waiting; nothing, errors included, outlives the call.
+type lookupGroup[T any] struct {
+	flight singleflight.Group
+}
+
+// do returns fn's result for key, joining a call already in flight. fn runs
+// detached from the caller's cancellation, so one caller going away does
+// not fail the others, and is bounded by the database's own timeouts
+// instead; each caller still stops waiting when its ctx is done. Callers
+// in a transaction run fn alone, as only they may see its writes.
+func (g *lookupGroup[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (*T, error)) (*T, error) {
+	if database.InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	ch := g.flight.DoChan(key, func() (interface{}, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Callers sharing the lookup each get their own copy
+		v := *res.Val.(*T)
+		return &v, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -3,113 +3,477 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/yourname/myapp/internal/models"
 	"github.com/yourname/myapp/internal/repositories"
+	"github.com/yourname/myapp/pkg/auth"
 	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/pagination"
+	"github.com/yourname/myapp/pkg/timing"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // CreateUserInput represents input for creating a user
 type CreateUserInput struct {
-	Email string `json:"email" binding:"required,email"`
-	Name  string `json:"name" binding:"required,min=2,max=100"`
+	Email    string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required,min=2,max=100"`
+	Password string `json:"password" binding:"required,min=8,maxbytes=72"` // bcrypt caps input at 72 bytes
 }
 
-// UpdateUserInput represents input for updating a user
+// Normalize trims the email and name and lowercases the email, so
+// addresses differing only in case or surrounding spaces are the same
+// account. Decoding from JSON normalizes before validation runs.
+func (in *CreateUserInput) Normalize() {
+	in.Email = normalizeEmail(in.Email)
+	in.Name = strings.TrimSpace(in.Name)
+}
+
+func (in *CreateUserInput) UnmarshalJSON(data []byte) error {
+	type plain CreateUserInput // drops methods to avoid recursion
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.Normalize()
+	return nil
+}
+
+// CreateUserBatchInput represents input for creating several users at once.
+// Items are validated individually so failures can be reported per item.
+type CreateUserBatchInput struct {
+	Users []CreateUserInput `json:"users" binding:"required,min=1"`
+}
+
+// UpdateUserInput replaces every writable field of a user
 type UpdateUserInput struct {
-	Name string `json:"name" binding:"omitempty,min=2,max=100"`
+	Name string `json:"name" binding:"required,min=2,max=100"`
+	// Version is the version the client last read; if set, the update is
+	// rejected when the user has changed since
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+// Normalize trims the name; see CreateUserInput.Normalize
+func (in *UpdateUserInput) Normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+}
+
+func (in *UpdateUserInput) UnmarshalJSON(data []byte) error {
+	type plain UpdateUserInput
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.Normalize()
+	return nil
+}
+
+// PatchUserInput changes only the fields it carries; fields that are
+// omitted or null are left as they are
+type PatchUserInput struct {
+	Name *string `json:"name" binding:"omitempty,min=2,max=100"`
+	// Version works as in UpdateUserInput
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+// Normalize trims the name if given; see CreateUserInput.Normalize
+func (in *PatchUserInput) Normalize() {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		in.Name = &name
+	}
+}
+
+func (in *PatchUserInput) UnmarshalJSON(data []byte) error {
+	type plain PatchUserInput
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.Normalize()
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // UserService defines the interface for user business logic
 type UserService interface {
 	Create(ctx context.Context, input CreateUserInput) (*models.User, error)
+	CreateBatch(ctx context.Context, inputs []CreateUserInput) ([]*models.User, []error)
 	GetByID(ctx context.Context, id string) (*models.User, error)
+	// Update and Delete require an auth.AuthUser in ctx that is the user
+	// being changed or an admin
 	Update(ctx context.Context, id string, input UpdateUserInput) (*models.User, error)
-	Delete(ctx context.Context, id string) error
+	Patch(ctx context.Context, id string, input PatchUserInput) (*models.User, error)
+	// Delete soft-deletes the user, or with hard, which only admins may
+	// use, removes it for good. Soft deleting a user that is already
+	// soft-deleted returns ErrUserNotFound; hard deleting one succeeds.
+	Delete(ctx context.Context, id string, hard bool) error
+	// List returns the page p describes, newest first if p.Sort is empty.
+	// Sort columns must have been checked against a whitelist.
+	List(ctx context.Context, p pagination.Params) ([]*models.User, int64, error)
+	ListAfter(ctx context.Context, cursor string, limit int) ([]*models.User, string, error)
+	Authenticate(ctx context.Context, email, password string) (*models.User, error)
+	// SetAvatar checks the caller may change the user, as Update does,
+	// then calls upload with the current avatar URL to store the image and
+	// saves the URL it returns. Errors from upload are returned as is.
+	SetAvatar(ctx context.Context, id string, upload func(ctx context.Context, current string) (string, error)) (*models.User, error)
+	// Export calls fn with every user, newest first, reading them as it
+	// goes rather than up front. Errors from fn are returned as is.
+	Export(ctx context.Context, fn func(*models.User) error) error
+}
+
+// Transactor runs fn atomically; repository calls made with the context
+// passed to fn share one transaction
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo    repositories.UserRepository
+	outbox  repositories.OutboxRepository
+	tx      Transactor
+	lookups lookupGroup[models.User]
 }
 
-// NewUserService creates a new UserService
-func NewUserService(repo repositories.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService creates a new UserService. Domain events are written to
+// outbox in the same transaction as the change they describe. Concurrent
+// GetByID calls for one user share a single query.
+func NewUserService(repo repositories.UserRepository, outbox repositories.OutboxRepository, tx Transactor) UserService {
+	return &userService{repo: repo, outbox: outbox, tx: tx}
 }
 
 func (s *userService) Create(ctx context.Context, input CreateUserInput) (*models.User, error) {
-	// Check if email already exists
-	existing, err := s.repo.FindByEmail(ctx, input.Email)
+	input.Normalize()
+
+	hash, err := hashPassword(ctx, input.Password)
 	if err != nil {
-		return nil, errors.Wrap(err, 500, "failed to check email")
-	}
-	if existing != nil {
-		return nil, errors.ErrUserExists
+		return nil, errors.Wrap(err, errors.KindInternal, "failed to hash password")
 	}
 
-	user := &models.User{
-		ID:        uuid.New().String(),
-		Email:     input.Email,
-		Name:      input.Name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
+	var saved *models.User
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// Check if email already exists, including soft-deleted users
+		existing, err := s.repo.FindByEmailWithDeleted(ctx, input.Email)
+		if err != nil {
+			return errors.Wrap(err, errors.KindInternal, "failed to check email")
+		}
+		if existing != nil {
+			return errors.ErrUserExists
+		}
 
-	saved, err := s.repo.Save(ctx, user)
+		// Built afresh on every attempt: Save sets the ID and version, and
+		// a retry after a rollback must insert again rather than update
+		saved, err = s.repo.Save(ctx, &models.User{
+			Email:    input.Email,
+			Name:     input.Name,
+			Password: string(hash),
+		})
+		if errors.Is(err, errors.ErrUserExists) {
+			return err
+		}
+		if err != nil {
+			return errors.Wrap(err, errors.KindInternal, "failed to save user")
+		}
+		return s.emitUserCreated(ctx, saved)
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, 500, "failed to save user")
+		return nil, err
 	}
 
 	return saved, nil
 }
 
-func (s *userService) GetByID(ctx context.Context, id string) (*models.User, error) {
-	user, err := s.repo.FindByID(ctx, id)
+// CreateBatch creates all users in one transaction. If any input fails,
+// nothing is saved and the returned errors are indexed like inputs, with
+// nil for inputs that were fine. A failure not tied to one input, such as
+// a database error, is reported against every input.
+func (s *userService) CreateBatch(ctx context.Context, inputs []CreateUserInput) ([]*models.User, []error) {
+	// Reject duplicates before paying for any hash. The unique index still
+	// catches emails taken between this check and the insert.
+	errs := make([]error, len(inputs))
+	failed := false
+	seen := make(map[string]bool, len(inputs))
+	inputs = append([]CreateUserInput(nil), inputs...)
+	for i := range inputs {
+		inputs[i].Normalize()
+		email := inputs[i].Email
+		if seen[email] {
+			errs[i], failed = errors.ErrUserExists, true
+			continue
+		}
+		seen[email] = true
+
+		existing, err := s.repo.FindByEmailWithDeleted(ctx, email)
+		if err != nil {
+			return nil, repeatError(errors.Wrap(err, errors.KindInternal, "failed to check email"), len(inputs))
+		}
+		if existing != nil {
+			errs[i], failed = errors.ErrUserExists, true
+		}
+	}
+	if failed {
+		return nil, errs
+	}
+
+	// Hash before opening the transaction; bcrypt is deliberately slow, so
+	// stop as soon as the caller gives up
+	hashes := make([][]byte, len(inputs))
+	for i, input := range inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, repeatError(errors.FromContext(err), len(inputs))
+		}
+		hash, err := hashPassword(ctx, input.Password)
+		if err != nil {
+			return nil, repeatError(errors.Wrap(err, errors.KindInternal, "failed to hash password"), len(inputs))
+		}
+		hashes[i] = hash
+	}
+
+	var users []*models.User
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// Every attempt starts over with new users, as Create does
+		users = make([]*models.User, len(inputs))
+		clear(errs)
+		for i, input := range inputs {
+			saved, err := s.repo.Save(ctx, &models.User{
+				Email:    input.Email,
+				Name:     input.Name,
+				Password: string(hashes[i]),
+			})
+			if errors.Is(err, errors.ErrUserExists) {
+				errs[i] = err
+				return errors.ErrConflict
+			}
+			if err != nil {
+				return errors.Wrap(err, errors.KindInternal, "failed to save user")
+			}
+			if err := s.emitUserCreated(ctx, saved); err != nil {
+				return err
+			}
+			users[i] = saved
+		}
+		return nil
+	})
+	if errors.Is(err, errors.ErrConflict) {
+		return nil, errs
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, 500, "failed to get user")
+		return nil, repeatError(err, len(inputs))
 	}
-	if user == nil {
-		return nil, errors.ErrUserNotFound
+
+	return users, nil
+}
+
+func repeatError(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
 	}
-	return user, nil
+	return errs
+}
+
+func (s *userService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	// Lookups are only shared within a tenant
+	return s.lookups.do(ctx, models.TenantFrom(ctx)+":"+id, func(ctx context.Context) (*models.User, error) {
+		user, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.KindInternal, "failed to get user")
+		}
+		if user == nil {
+			return nil, errors.ErrUserNotFound
+		}
+		return user, nil
+	})
 }
 
 func (s *userService) Update(ctx context.Context, id string, input UpdateUserInput) (*models.User, error) {
+	input.Normalize()
+	return s.update(ctx, id, input.Version, func(user *models.User) error {
+		user.Name = input.Name
+		return nil
+	})
+}
+
+func (s *userService) Patch(ctx context.Context, id string, input PatchUserInput) (*models.User, error) {
+	input.Normalize()
+	return s.update(ctx, id, input.Version, func(user *models.User) error {
+		if input.Name != nil {
+			user.Name = *input.Name
+		}
+		return nil
+	})
+}
+
+func (s *userService) SetAvatar(ctx context.Context, id string, upload func(ctx context.Context, current string) (string, error)) (*models.User, error) {
+	return s.update(ctx, id, 0, func(user *models.User) error {
+		url, err := upload(ctx, user.AvatarURL)
+		if err != nil {
+			return err
+		}
+		user.AvatarURL = url
+		return nil
+	})
+}
+
+// update loads the user, lets apply change it and saves it, checking
+// version if it is not zero. An error from apply is returned as is.
+func (s *userService) update(ctx context.Context, id string, version int, apply func(*models.User) error) (*models.User, error) {
+	if err := authorizeOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		return nil, errors.Wrap(err, 500, "failed to get user")
+		return nil, errors.Wrap(err, errors.KindInternal, "failed to get user")
 	}
 	if user == nil {
 		return nil, errors.ErrUserNotFound
 	}
 
-	if input.Name != "" {
-		user.Name = input.Name
+	if version != 0 {
+		user.Version = version
+	}
+	if err := apply(user); err != nil {
+		return nil, err
 	}
 	user.UpdatedAt = time.Now()
 
 	saved, err := s.repo.Save(ctx, user)
+	if errors.Is(err, errors.ErrVersionConflict) {
+		return nil, err
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, 500, "failed to update user")
+		return nil, errors.Wrap(err, errors.KindInternal, "failed to update user")
 	}
 
 	return saved, nil
 }
 
-func (s *userService) Delete(ctx context.Context, id string) error {
-	user, err := s.repo.FindByID(ctx, id)
+func (s *userService) Delete(ctx context.Context, id string, hard bool) error {
+	if err := authorizeOwner(ctx, id); err != nil {
+		return err
+	}
+	if hard {
+		if caller, _ := auth.UserFromContext(ctx); !caller.IsAdmin() {
+			return errors.ErrForbidden
+		}
+	}
+
+	find, remove := s.repo.FindByID, s.repo.Delete
+	if hard {
+		// Purging also covers users that were soft-deleted before
+		find, remove = s.repo.FindByIDWithDeleted, s.repo.HardDelete
+	}
+
+	user, err := find(ctx, id)
 	if err != nil {
-		return errors.Wrap(err, 500, "failed to get user")
+		return errors.Wrap(err, errors.KindInternal, "failed to get user")
 	}
 	if user == nil {
 		return errors.ErrUserNotFound
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return errors.Wrap(err, 500, "failed to delete user")
+	if err := remove(ctx, id); err != nil {
+		return errors.Wrap(err, errors.KindInternal, "failed to delete user")
+	}
+
+	return nil
+}
+
+func (s *userService) List(ctx context.Context, p pagination.Params) ([]*models.User, int64, error) {
+	sort := make([]repositories.Sort, len(p.Sort))
+	for i, f := range p.Sort {
+		sort[i] = repositories.Sort{Column: f.Column, Desc: f.Desc}
+	}
+
+	users, total, err := s.repo.List(ctx, p.Offset(), p.PageSize, sort)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, errors.KindInternal, "failed to list users")
+	}
+	return users, total, nil
+}
+
+// ListAfter returns up to limit users after the opaque cursor ("" for the
+// first page) along with the next page's cursor, or "" on the last page
+func (s *userService) ListAfter(ctx context.Context, cursor string, limit int) ([]*models.User, string, error) {
+	var after *repositories.Cursor
+	if cursor != "" {
+		var err error
+		if after, err = repositories.DecodeCursor(cursor); err != nil {
+			return nil, "", errors.Validation("invalid cursor")
+		}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	users, err := s.repo.ListAfter(ctx, after, limit+1)
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.KindInternal, "failed to list users")
+	}
+	if len(users) <= limit {
+		return users, "", nil
 	}
 
+	users = users[:limit]
+	last := users[limit-1]
+	return users, repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode(), nil
+}
+
+func (s *userService) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.repo.FindByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.KindInternal, "failed to get user")
+	}
+
+	// Unknown emails cost a comparison too, so response times do not
+	// reveal which emails are registered
+	hash := dummyPasswordHash
+	if user != nil {
+		hash = user.Password
+	}
+	stop := timing.Track(ctx, "hash")
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	stop()
+	if user == nil || err != nil {
+		return nil, errors.ErrUnauthorized
+	}
+	return user, nil
+}
+
+// dummyPasswordHash is a bcrypt hash at bcrypt.DefaultCost that matches
+// no password a client would send
+const dummyPasswordHash = "$2a$10$I.8Qo1M4B48.d8Ya7JAe0.ekW3vNMdYCaL1P0RXJZ4JvWq1eqb67G"
+
+func (s *userService) Export(ctx context.Context, fn func(*models.User) error) error {
+	var fnErr error
+	err := s.repo.Each(ctx, func(user *models.User) error {
+		fnErr = fn(user)
+		return fnErr
+	})
+	if err != nil && err != fnErr {
+		return errors.Wrap(err, errors.KindInternal, "failed to export users")
+	}
+	return err
+}
+
+// hashPassword bcrypt-hashes password, timed as the hash phase since it is
+// deliberately slow
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+	defer timing.Track(ctx, "hash")()
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// authorizeOwner allows the caller in ctx to modify the user with id if it
+// is that user or an admin. It runs before the lookup so callers cannot
+// probe which IDs exist.
+func authorizeOwner(ctx context.Context, id string) error {
+	caller, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return errors.ErrUnauthorized
+	}
+	if !caller.CanActOn(id) {
+		return errors.ErrForbidden
+	}
 	return nil
 }
@@ -0,0 +1,107 @@
+// internal/services/user_cache.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/pkg/cache"
+)
+
+// cachedUserService caches GetByID in front of another UserService
+type cachedUserService struct {
+	UserService
+	cache   cache.Cache
+	ttl     time.Duration
+	lookups lookupGroup[models.User]
+
+	// evictions counts evict calls. A lookup that saw it change may have
+	// read a user from before the write, so it does not cache the result.
+	evictions atomic.Uint64
+}
+
+// NewCachedUserService wraps next so GetByID is served from c for ttl.
+// Update, Patch and Delete evict the user, whether or not they succeed.
+// Concurrent misses for one user share a single lookup; one that raced
+// a write in this process leaves the cache alone. A write made through
+// another instance can still leave a stale entry, for at most ttl. Cache
+// failures are logged and fall through to next, so an outage only costs
+// speed.
+func NewCachedUserService(next UserService, c cache.Cache, ttl time.Duration) UserService {
+	return &cachedUserService{UserService: next, cache: c, ttl: ttl}
+}
+
+func (s *cachedUserService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	key := userCacheKey(ctx, id)
+	if b, ok, err := s.cache.Get(ctx, key); err != nil {
+		slog.WarnContext(ctx, "user cache get failed", "id", id, "error", err)
+	} else if ok {
+		var user models.User
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&user); err == nil {
+			return &user, nil
+		}
+		// Likely written by an older version of the model; reload it
+	}
+
+	// Concurrent misses share one lookup and one cache write
+	return s.lookups.do(ctx, key, func(ctx context.Context) (*models.User, error) {
+		evictions := s.evictions.Load()
+		user, err := s.UserService.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if s.evictions.Load() != evictions {
+			return user, nil
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(user); err != nil {
+			slog.WarnContext(ctx, "user cache encode failed", "id", id, "error", err)
+		} else if err := s.cache.Set(ctx, key, buf.Bytes(), s.ttl); err != nil {
+			slog.WarnContext(ctx, "user cache set failed", "id", id, "error", err)
+		} else if s.evictions.Load() != evictions {
+			// An eviction landed between the check and the write
+			s.evict(ctx, id)
+		}
+		return user, nil
+	})
+}
+
+func (s *cachedUserService) Update(ctx context.Context, id string, input UpdateUserInput) (*models.User, error) {
+	defer s.evict(ctx, id)
+	return s.UserService.Update(ctx, id, input)
+}
+
+func (s *cachedUserService) Patch(ctx context.Context, id string, input PatchUserInput) (*models.User, error) {
+	defer s.evict(ctx, id)
+	return s.UserService.Patch(ctx, id, input)
+}
+
+func (s *cachedUserService) SetAvatar(ctx context.Context, id string, upload func(ctx context.Context, current string) (string, error)) (*models.User, error) {
+	defer s.evict(ctx, id)
+	return s.UserService.SetAvatar(ctx, id, upload)
+}
+
+func (s *cachedUserService) Delete(ctx context.Context, id string, hard bool) error {
+	defer s.evict(ctx, id)
+	return s.UserService.Delete(ctx, id, hard)
+}
+
+// evict drops id from the cache. A failure leaves the entry to expire.
+func (s *cachedUserService) evict(ctx context.Context, id string) {
+	s.evictions.Add(1)
+	if err := s.cache.Delete(ctx, userCacheKey(ctx, id)); err != nil {
+		slog.WarnContext(ctx, "user cache evict failed", "id", id, "error", err)
+	}
+}
+
+// userCacheKey names the user id of the context's tenant, so no tenant is
+// served another's cached user
+func userCacheKey(ctx context.Context, id string) string {
+	return "user:" + models.TenantFrom(ctx) + ":" + id
+}
@@ -0,0 +1,133 @@
+// internal/services/user_cache_test.go
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories/mocks"
+	"github.com/yourname/myapp/pkg/auth"
+	"github.com/yourname/myapp/pkg/cache"
+)
+
+func TestCachedGetByIDSharesConcurrentMisses(t *testing.T) {
+	release := make(chan struct{})
+	repo := &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			<-release
+			return &models.User{Name: "Foo"}, nil
+		},
+	}
+	svc := NewCachedUserService(newMockService(repo), cache.NewLRU(10), time.Minute)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if user, err := svc.GetByID(context.Background(), "1"); err != nil || user.Name != "Foo" {
+				t.Errorf("GetByID = %+v, %v", user, err)
+			}
+		}()
+	}
+	// Give the callers time to pile up behind the first lookup
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if _, err := svc.GetByID(context.Background(), "1"); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if n := repo.Called("FindByID"); n != 1 {
+		t.Errorf("FindByID called %d times, want 1", n)
+	}
+}
+
+func TestCachedUserEvictedOnUpdate(t *testing.T) {
+	stored := &models.User{Name: "Foo"}
+	stored.ID = "1"
+	repo := &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			user := *stored
+			return &user, nil
+		},
+		SaveFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			stored = user
+			return user, nil
+		},
+	}
+	svc := NewCachedUserService(newMockService(repo), cache.NewLRU(10), time.Minute)
+	ctx := auth.WithUser(context.Background(), &auth.AuthUser{ID: "1"})
+
+	if _, err := svc.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if _, err := svc.Update(ctx, "1", UpdateUserInput{Name: "Bar"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	user, err := svc.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if user.Name != "Bar" {
+		t.Errorf("name = %q after update, want %q", user.Name, "Bar")
+	}
+}
+
+func TestCachedLookupRacingUpdateIsNotCached(t *testing.T) {
+	stored := &models.User{Name: "Foo"}
+	stored.ID = "1"
+	loading, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	first := true
+	repo := &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			mu.Lock()
+			user := *stored
+			wait := first
+			first = false
+			mu.Unlock()
+			// The first lookup reads the user, then stalls past the update
+			if wait {
+				close(loading)
+				<-release
+			}
+			return &user, nil
+		},
+		SaveFunc: func(ctx context.Context, user *models.User) (*models.User, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			stored = user
+			return user, nil
+		},
+	}
+	svc := NewCachedUserService(newMockService(repo), cache.NewLRU(10), time.Minute)
+	ctx := auth.WithUser(context.Background(), &auth.AuthUser{ID: "1"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := svc.GetByID(ctx, "1"); err != nil {
+			t.Errorf("GetByID: %v", err)
+		}
+	}()
+	<-loading
+	if _, err := svc.Update(ctx, "1", UpdateUserInput{Name: "Bar"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	close(release)
+	<-done
+
+	user, err := svc.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if user.Name != "Bar" {
+		t.Errorf("name = %q after the update, want %q", user.Name, "Bar")
+	}
+}
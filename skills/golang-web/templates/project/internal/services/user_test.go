@@ -0,0 +1,421 @@
+// internal/services/user_test.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourname/myapp/internal/models"
+	"github.com/yourname/myapp/internal/repositories"
+	"github.com/yourname/myapp/internal/repositories/mocks"
+	"github.com/yourname/myapp/migrations"
+	"github.com/yourname/myapp/pkg/auth"
+	"github.com/yourname/myapp/pkg/ctxkeys"
+	"github.com/yourname/myapp/pkg/database"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/migrate"
+	"github.com/yourname/myapp/pkg/pagination"
+	"github.com/yourname/myapp/pkg/retry"
+)
+
+// newTestService returns a UserService backed by a migrated SQLite
+// database private to the test
+func newTestService(t *testing.T) (UserService, repositories.UserRepository) {
+	t.Helper()
+
+	db := newTestDB(t)
+	repo := repositories.NewUserRepository(db.DB())
+	return NewUserService(repo, repositories.NewOutboxRepository(db.DB()), db), repo
+}
+
+// newTestDB opens and migrates a SQLite database private to the test
+func newTestDB(t *testing.T, opts ...database.Option) *database.Database {
+	t.Helper()
+
+	db, err := database.New(database.Config{
+		Driver:   "sqlite",
+		Database: filepath.Join(t.TempDir(), "test.db"),
+	}, append([]database.Option{database.WithTenantScope(models.TenantFrom)}, opts...)...)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	sqlDB, err := db.DB().DB()
+	if err != nil {
+		t.Fatalf("get connection pool: %v", err)
+	}
+	m, err := migrate.New(sqlDB, "sqlite", migrations.FS)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// serializationFailure is a Postgres error with SQLSTATE 40001
+type serializationFailure struct{}
+
+func (serializationFailure) Error() string    { return "could not serialize access" }
+func (serializationFailure) SQLState() string { return "40001" }
+
+// flakyOutbox fails the failAt-th Add with a serialization failure, as a
+// database aborting the transaction would
+type flakyOutbox struct {
+	repositories.OutboxRepository
+	failAt int
+	adds   int
+}
+
+func (o *flakyOutbox) Add(ctx context.Context, event *models.OutboxEvent) error {
+	if o.adds++; o.adds == o.failAt {
+		return serializationFailure{}
+	}
+	return o.OutboxRepository.Add(ctx, event)
+}
+
+func TestCreateRetriesSerializationFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(svc UserService) error
+		failAt int
+		emails []string
+	}{
+		{"create", func(svc UserService) error {
+			_, err := svc.Create(context.Background(), CreateUserInput{Email: "a@bar.com", Name: "A", Password: "password123"})
+			return err
+		}, 1, []string{"a@bar.com"}},
+		// The first user is saved before the second attempt's rollback
+		{"create batch", func(svc UserService) error {
+			_, errs := svc.CreateBatch(context.Background(), []CreateUserInput{
+				{Email: "a@bar.com", Name: "A", Password: "password123"},
+				{Email: "b@bar.com", Name: "B", Password: "password123"},
+			})
+			return stderrors.Join(errs...)
+		}, 2, []string{"a@bar.com", "b@bar.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, database.WithTxRetry(retry.Policy{MaxAttempts: 3}))
+			repo := repositories.NewUserRepository(db.DB())
+			outbox := &flakyOutbox{OutboxRepository: repositories.NewOutboxRepository(db.DB()), failAt: tt.failAt}
+			svc := NewUserService(repo, outbox, db)
+
+			if err := tt.create(svc); err != nil {
+				t.Fatalf("create after a serialization failure: %v", err)
+			}
+			for _, email := range tt.emails {
+				if user, err := repo.FindByEmail(context.Background(), email); err != nil || user == nil || user.Version != 1 {
+					t.Errorf("user %s = %+v (%v), want it saved once", email, user, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateNormalizesInput(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	user, err := svc.Create(context.Background(), CreateUserInput{
+		Email:    "  New.User@Example.COM ",
+		Name:     " Ann ",
+		Password: "password123",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if user.Email != "new.user@example.com" {
+		t.Errorf("Email = %q, want %q", user.Email, "new.user@example.com")
+	}
+	if user.Name != "Ann" {
+		t.Errorf("Name = %q, want %q", user.Name, "Ann")
+	}
+}
+
+func TestCreateRejectsEmailDifferingOnlyInCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+	}{
+		{"normalized row", "foo@bar.com"},
+		// Rows written before normalization may still be mixed case
+		{"mixed case row", "Foo@bar.COM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestService(t)
+			ctx := context.Background()
+
+			if _, err := repo.Save(ctx, &models.User{Email: tt.existing, Name: "Foo", Password: "x"}); err != nil {
+				t.Fatalf("seed user: %v", err)
+			}
+
+			_, err := svc.Create(ctx, CreateUserInput{
+				Email:    "Foo@Bar.com ",
+				Name:     "Foo",
+				Password: "password123",
+			})
+			if !errors.Is(err, errors.ErrUserExists) {
+				t.Fatalf("Create error = %v, want ErrUserExists", err)
+			}
+		})
+	}
+}
+
+func TestCreateBatchRejectsDuplicatesDifferingOnlyInCase(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	_, errs := svc.CreateBatch(context.Background(), []CreateUserInput{
+		{Email: "foo@bar.com", Name: "Foo", Password: "password123"},
+		{Email: "Foo@Bar.com ", Name: "Foo", Password: "password123"},
+	})
+	if errs == nil {
+		t.Fatal("CreateBatch succeeded, want a conflict")
+	}
+	if errs[0] != nil {
+		t.Errorf("errs[0] = %v, want nil", errs[0])
+	}
+	if !errors.Is(errs[1], errors.ErrUserExists) {
+		t.Errorf("errs[1] = %v, want ErrUserExists", errs[1])
+	}
+}
+
+func TestCreateBatchStopsWhenCanceled(t *testing.T) {
+	svc, repo := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, errs := svc.CreateBatch(ctx, []CreateUserInput{
+		{Email: "foo@bar.com", Name: "Foo", Password: "password123"},
+	})
+	if errs == nil || !errors.Is(errs[0], context.Canceled) {
+		t.Fatalf("CreateBatch = %v, want context.Canceled", errs)
+	}
+	if user, _ := repo.FindByEmail(context.Background(), "foo@bar.com"); user != nil {
+		t.Error("canceled batch saved a user")
+	}
+}
+
+func TestFindByEmailIgnoresCase(t *testing.T) {
+	_, repo := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := repo.Save(ctx, &models.User{Email: "foo@bar.com", Name: "Foo", Password: "x"}); err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+
+	user, err := repo.FindByEmail(ctx, "FOO@Bar.Com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if user == nil {
+		t.Fatal("FindByEmail found no user")
+	}
+}
+
+func TestInputsNormalizeWhenDecoded(t *testing.T) {
+	var create CreateUserInput
+	if err := json.Unmarshal([]byte(`{"email":" Foo@Bar.com ","name":" Foo ","password":"secret"}`), &create); err != nil {
+		t.Fatalf("decode CreateUserInput: %v", err)
+	}
+	if create.Email != "foo@bar.com" || create.Name != "Foo" || create.Password != "secret" {
+		t.Errorf("CreateUserInput = %+v", create)
+	}
+
+	var update UpdateUserInput
+	if err := json.Unmarshal([]byte(`{"name":" Foo ","version":2}`), &update); err != nil {
+		t.Fatalf("decode UpdateUserInput: %v", err)
+	}
+	if update.Name != "Foo" || update.Version != 2 {
+		t.Errorf("UpdateUserInput = %+v", update)
+	}
+}
+
+// newMockService returns a UserService backed by repo and in-memory
+// doubles, for tests that need no database
+func newMockService(repo *mocks.UserRepository) UserService {
+	return NewUserService(repo, &mocks.OutboxRepository{}, mocks.Transactor{})
+}
+
+func TestCreateWithExistingEmail(t *testing.T) {
+	repo := &mocks.UserRepository{
+		FindByEmailWithDeletedFunc: func(ctx context.Context, email string) (*models.User, error) {
+			return &models.User{Email: email}, nil
+		},
+	}
+	svc := newMockService(repo)
+
+	_, err := svc.Create(context.Background(), CreateUserInput{
+		Email:    "foo@bar.com",
+		Name:     "Foo",
+		Password: "password123",
+	})
+	if !errors.Is(err, errors.ErrUserExists) {
+		t.Fatalf("Create error = %v, want ErrUserExists", err)
+	}
+	if n := repo.Called("Save"); n != 0 {
+		t.Errorf("Save called %d times, want 0", n)
+	}
+}
+
+func TestCreateEmitsUserCreated(t *testing.T) {
+	outbox := &mocks.OutboxRepository{}
+	svc := NewUserService(&mocks.UserRepository{}, outbox, mocks.Transactor{})
+
+	if _, err := svc.Create(context.Background(), CreateUserInput{
+		Email:    "foo@bar.com",
+		Name:     "Foo",
+		Password: "password123",
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	events := outbox.Events()
+	if len(events) != 1 || events[0].Type != EventUserCreated {
+		t.Fatalf("events = %+v, want one %s", events, EventUserCreated)
+	}
+}
+
+func TestUserNotFound(t *testing.T) {
+	admin := auth.WithUser(context.Background(), &auth.AuthUser{ID: "admin", Roles: []string{auth.RoleAdmin}})
+
+	tests := []struct {
+		name string
+		call func(UserService) error
+	}{
+		{"GetByID", func(svc UserService) error {
+			_, err := svc.GetByID(admin, "missing")
+			return err
+		}},
+		{"Update", func(svc UserService) error {
+			_, err := svc.Update(admin, "missing", UpdateUserInput{Name: "Foo"})
+			return err
+		}},
+		{"Patch", func(svc UserService) error {
+			name := "Foo"
+			_, err := svc.Patch(admin, "missing", PatchUserInput{Name: &name})
+			return err
+		}},
+		{"Delete", func(svc UserService) error {
+			return svc.Delete(admin, "missing", false)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// FindByID is unset, so every lookup finds nothing
+			repo := &mocks.UserRepository{}
+
+			if err := tt.call(newMockService(repo)); !errors.Is(err, errors.ErrUserNotFound) {
+				t.Fatalf("error = %v, want ErrUserNotFound", err)
+			}
+			if n := repo.Called("Save") + repo.Called("Delete"); n != 0 {
+				t.Errorf("repository modified %d times, want 0", n)
+			}
+		})
+	}
+}
+
+func TestGetByIDWrapsRepositoryErrors(t *testing.T) {
+	dbErr := stderrors.New("connection refused")
+	repo := &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			return nil, dbErr
+		},
+	}
+
+	_, err := newMockService(repo).GetByID(context.Background(), "1")
+	var appErr *errors.AppError
+	if !errors.As(err, &appErr) || appErr.Kind != errors.KindInternal {
+		t.Fatalf("error = %v, want a KindInternal AppError", err)
+	}
+	if !errors.Is(err, dbErr) {
+		t.Errorf("error = %v, want it to wrap %v", err, dbErr)
+	}
+}
+
+func TestGetByIDCoalescesWithPerCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	repo := &mocks.UserRepository{
+		FindByIDFunc: func(ctx context.Context, id string) (*models.User, error) {
+			<-release
+			return &models.User{Name: "Foo"}, ctx.Err()
+		},
+	}
+	svc := newMockService(repo)
+
+	// The caller that starts the lookup gives up on it
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := svc.GetByID(ctx, "1")
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan *models.User, 1)
+	go func() {
+		user, err := svc.GetByID(context.Background(), "1")
+		if err != nil {
+			t.Errorf("GetByID: %v", err)
+		}
+		second <- user
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-first; !stderrors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if user := <-second; user == nil || user.Name != "Foo" {
+		t.Errorf("waiting caller got %+v, want the shared result", user)
+	}
+	if n := repo.Called("FindByID"); n != 1 {
+		t.Errorf("FindByID called %d times, want 1", n)
+	}
+}
+
+func TestUsersAreScopedToTenant(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctxA := ctxkeys.WithTenantID(context.Background(), "acme")
+	ctxB := ctxkeys.WithTenantID(context.Background(), "globex")
+	input := CreateUserInput{Email: "foo@bar.com", Name: "Foo", Password: "password123"}
+
+	// Emails are unique per tenant
+	userA, err := svc.Create(ctxA, input)
+	if err != nil {
+		t.Fatalf("Create in acme: %v", err)
+	}
+	if userA.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", userA.TenantID)
+	}
+	if _, err := svc.Create(ctxB, input); err != nil {
+		t.Fatalf("Create in globex: %v", err)
+	}
+	if _, err := svc.Create(ctxA, input); !errors.Is(err, errors.ErrUserExists) {
+		t.Fatalf("second Create in acme = %v, want ErrUserExists", err)
+	}
+
+	// Other tenants' users are invisible
+	if _, err := svc.GetByID(ctxB, userA.ID); !errors.Is(err, errors.ErrUserNotFound) {
+		t.Errorf("GetByID from globex = %v, want ErrUserNotFound", err)
+	}
+	if _, err := svc.GetByID(context.Background(), userA.ID); !errors.Is(err, errors.ErrUserNotFound) {
+		t.Errorf("GetByID from the default tenant = %v, want ErrUserNotFound", err)
+	}
+	users, total, err := svc.List(ctxB, pagination.Params{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 1 || len(users) != 1 || users[0].TenantID != "globex" {
+		t.Errorf("List in globex = %d users of %d, want only its own", len(users), total)
+	}
+}
@@ -0,0 +1,9 @@
+// migrations/embed.go
+package migrations
+
+import "embed"
+
+// FS holds versioned SQL migrations, one directory per database driver
+//
+//go:embed postgres mysql sqlite
+var FS embed.FS
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         VARCHAR(36) PRIMARY KEY,
+    email      VARCHAR(255) NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    password   VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    deleted_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_users_email (email),
+    INDEX idx_users_deleted_at (deleted_at)
+);
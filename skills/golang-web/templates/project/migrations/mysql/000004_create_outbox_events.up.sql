@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              VARCHAR(36) PRIMARY KEY,
+    type            VARCHAR(100) NOT NULL,
+    aggregate_id    VARCHAR(36) NOT NULL,
+    payload         TEXT NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(3) NOT NULL,
+    last_error      TEXT NULL,
+    created_at      DATETIME(3) NOT NULL,
+    sent_at         DATETIME(3) NULL,
+    INDEX idx_outbox_events_pending (sent_at, next_attempt_at)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP INDEX idx_users_tenant_email,
+    ADD UNIQUE INDEX idx_users_email (email),
+    DROP COLUMN tenant_id;
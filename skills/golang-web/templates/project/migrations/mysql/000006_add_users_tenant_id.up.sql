@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    DROP INDEX idx_users_email,
+    ADD UNIQUE INDEX idx_users_tenant_email (tenant_id, email);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         VARCHAR(36) PRIMARY KEY,
+    email      VARCHAR(255) NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    password   VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
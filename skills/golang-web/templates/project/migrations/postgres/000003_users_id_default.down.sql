@@ -0,0 +1 @@
+ALTER TABLE users ALTER COLUMN id DROP DEFAULT;
@@ -0,0 +1,2 @@
+-- Lets the "database" id strategy leave ID generation to Postgres (13+)
+ALTER TABLE users ALTER COLUMN id SET DEFAULT gen_random_uuid()::text;
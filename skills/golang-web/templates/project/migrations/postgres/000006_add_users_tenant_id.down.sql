@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_tenant_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+ALTER TABLE users DROP COLUMN tenant_id;
@@ -0,0 +1,73 @@
+// pkg/alert/alert.go
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// ErrorReporter notifies an error tracker, such as Sentry, of server errors
+// and panics so they reach someone beyond the log. Report is called on the
+// request path and must not block on the network.
+type ErrorReporter interface {
+	Report(ctx context.Context, e Event)
+}
+
+// Event is one error to report. A panic arrives as a *PanicError, possibly
+// wrapped.
+type Event struct {
+	Err       error
+	Request   *http.Request
+	RequestID string
+}
+
+// Nop discards every event; it is the reporter until SetReporter is called
+type Nop struct{}
+
+func (Nop) Report(context.Context, Event) {}
+
+// reporter receives the events passed to Report
+var reporter ErrorReporter = Nop{}
+
+// SetReporter makes r receive every event passed to Report. Call it once
+// at startup.
+func SetReporter(r ErrorReporter) {
+	reporter = r
+}
+
+// Report sends e to the reporter set with SetReporter
+func Report(ctx context.Context, e Event) {
+	reporter.Report(ctx, e)
+}
+
+// PanicError is a recovered panic. Created in the deferred function that
+// recovered it, its stack trace leads to where the panic happened.
+type PanicError struct {
+	Value interface{}
+	stack []uintptr
+}
+
+// NewPanicError wraps value, as returned by recover, and captures the stack
+func NewPanicError(value interface{}) *PanicError {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	return &PanicError{Value: value, stack: pcs[:n]}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the program counters of the panicking goroutine.
+// Error trackers pick it up by this method, as they do for AppError.
+func (e *PanicError) StackTrace() []uintptr {
+	return e.stack
+}
@@ -0,0 +1,70 @@
+// pkg/alert/sentry.go
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// flushTimeout bounds Flush when its context has no deadline
+const flushTimeout = 2 * time.Second
+
+// Sentry reports events to Sentry. Events are sent in the background and
+// may be lost if the process exits without calling Flush.
+type Sentry struct {
+	client *sentry.Client
+}
+
+// NewSentry creates a Sentry reporter for the project dsn identifies,
+// tagging events with environment, e.g. "production"
+func NewSentry(dsn, environment string) (*Sentry, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sentry client: %w", err)
+	}
+	return &Sentry{client: client}, nil
+}
+
+// Report queues e for sending, tagged with the request and trace IDs.
+// Request headers carrying credentials are left out.
+func (s *Sentry) Report(ctx context.Context, e Event) {
+	scope := sentry.NewScope()
+	if e.Request != nil {
+		scope.SetRequest(e.Request)
+	}
+	if e.RequestID != "" {
+		scope.SetTag("request_id", e.RequestID)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		scope.SetTag("trace_id", sc.TraceID().String())
+	}
+
+	hint := &sentry.EventHint{Context: ctx, OriginalException: e.Err}
+	var p *PanicError
+	if errors.As(e.Err, &p) {
+		hint.RecoveredException = p.Value
+		scope.SetLevel(sentry.LevelFatal)
+	}
+	s.client.CaptureException(e.Err, hint, scope)
+}
+
+// Flush waits until queued events are sent or ctx expires, for use as a
+// shutdown hook
+func (s *Sentry) Flush(ctx context.Context) error {
+	timeout := flushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if !s.client.Flush(timeout) {
+		return errors.New("sentry: timed out sending queued events")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// pkg/alert/sentry_test.go
+package alert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSentryReport(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, err := NewSentry("http://key@"+strings.TrimPrefix(srv.URL, "http://")+"/1", "test")
+	if err != nil {
+		t.Fatalf("NewSentry: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	s.Report(context.Background(), Event{
+		Err:       errors.New("failed to get user: connection refused"),
+		Request:   req,
+		RequestID: "req-1",
+	})
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("sentry received %d events, want 1", len(bodies))
+	}
+	for _, want := range []string{"connection refused", `"request_id":"req-1"`, "/api/v1/users/1", `"environment":"test"`} {
+		if !strings.Contains(bodies[0], want) {
+			t.Errorf("event lacks %s:\n%s", want, bodies[0])
+		}
+	}
+	if strings.Contains(bodies[0], `"Authorization":`) {
+		t.Error("event includes the Authorization header")
+	}
+}
+
+func TestPanicErrorStack(t *testing.T) {
+	var p *PanicError
+	func() {
+		defer func() { p = NewPanicError(recover()) }()
+		panic("boom")
+	}()
+
+	if p.Error() != "panic: boom" {
+		t.Errorf("Error() = %q, want panic: boom", p.Error())
+	}
+	if len(p.StackTrace()) == 0 {
+		t.Error("StackTrace() is empty")
+	}
+}
@@ -0,0 +1,48 @@
+// pkg/auth/context.go
+package auth
+
+import "context"
+
+// RoleAdmin is the role allowed to act on any user's resources
+const RoleAdmin = "admin"
+
+// AuthUser is the authenticated caller of a request
+type AuthUser struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+// HasRole reports whether the user has role
+func (u *AuthUser) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the user has RoleAdmin
+func (u *AuthUser) IsAdmin() bool {
+	return u.HasRole(RoleAdmin)
+}
+
+// CanActOn reports whether the user may modify resources owned by the
+// user with ownerID, which is true for the owner and for admins
+func (u *AuthUser) CanActOn(ownerID string) bool {
+	return u.ID == ownerID || u.IsAdmin()
+}
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying user
+func WithUser(ctx context.Context, user *AuthUser) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the user stored in ctx, if any
+func UserFromContext(ctx context.Context) (*AuthUser, bool) {
+	user, ok := ctx.Value(contextKey{}).(*AuthUser)
+	return user, ok && user != nil
+}
@@ -0,0 +1,16 @@
+// pkg/cache/cache.go
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores byte values under string keys for a limited time. LRU
+// caches per process; Redis shares one cache between replicas.
+type Cache interface {
+	// Get reports false if key is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,37 @@
+// pkg/ctxkeys/keys.go
+package ctxkeys
+
+import "context"
+
+type contextKey string
+
+const (
+	RequestIDKey contextKey = "request_id"
+	TenantIDKey  contextKey = "tenant_id"
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "" if absent
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(RequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// WithTenantID returns a copy of ctx scoped to the tenant id
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, TenantIDKey, id)
+}
+
+// TenantID returns the tenant ID stored in ctx, or "" if absent
+func TenantID(ctx context.Context) string {
+	if id, ok := ctx.Value(TenantIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -2,12 +2,20 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"net/url"
+	"sync"
+	"time"
 
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/pkg/retry"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 // Config holds database configuration
@@ -19,38 +27,213 @@ type Config struct {
 	Password string
 	Database string
 	SSLMode  string
+
+	// MySQL only; default to utf8mb4 and Local
+	Charset string
+	Loc     string
+
+	// Postgres only: the server cancels statements running longer than
+	// StatementTimeout and ones waiting longer than LockTimeout for a lock,
+	// whatever the Go context says. Zero leaves the server's setting.
+	// Replica DSNs carry their own, as statement_timeout=<ms>.
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+
+	// Read replica DSNs in the driver's native format. When set, queries
+	// outside a transaction are spread across the replicas and writes go to
+	// the primary; an explicit clause, dbresolver.Write, forces a primary read.
+	Replicas []string
+
+	// Connection pool, applied to each replica as well; zero values keep
+	// the database/sql defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// FromConfig builds a Config from one entry of the app config
+func FromConfig(cfg configs.DatabaseConfig) Config {
+	return Config{
+		Driver:   cfg.Driver,
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Database: cfg.Database,
+		SSLMode:  cfg.SSLMode,
+		Charset:  cfg.Charset,
+		Loc:      cfg.Loc,
+		Replicas: cfg.Replicas,
+
+		StatementTimeout: cfg.StatementTimeout,
+		LockTimeout:      cfg.LockTimeout,
+
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
 }
 
 // Database wraps gorm.DB
 type Database struct {
 	db *gorm.DB
+
+	maxIdleConns      int
+	reconnectInterval time.Duration
+	queryLog          logger.Interface
+	txRetry           retry.Policy // zero MaxAttempts runs transactions once
+	tenant            func(context.Context) string
+
+	mu       sync.RWMutex
+	downErr  error // last failed ping while the connection is lost
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Option configures a Database
+type Option func(*Database)
+
+// WithReconnect pings the database every interval. While pings fail, the
+// pool's idle connections are dropped, queries fail fast with
+// errors.ErrUnavailable and Check reports the outage. Zero disables it.
+func WithReconnect(interval time.Duration) Option {
+	return func(d *Database) {
+		d.reconnectInterval = interval
+	}
 }
 
-// New creates a new database connection
-func New(cfg Config) (*Database, error) {
-	var dialector gorm.Dialector
+type txKey struct{}
+
+// New creates a new database connection. Each call returns an independent
+// Database with its own pool, so apps with several datastores call it once
+// per entry of configs.Config.Databases.
+func New(cfg Config, opts ...Option) (*Database, error) {
+	d := &Database{queryLog: logger.Default.LogMode(logger.Silent), stop: make(chan struct{})}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	var dsn string
 
 	switch cfg.Driver {
 	case "postgres":
-		dsn := fmt.Sprintf(
+		dsn = fmt.Sprintf(
 			"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 			cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.SSLMode,
 		)
-		dialector = postgres.Open(dsn)
+		// Unknown keys are sent as run-time parameters when each connection
+		// starts, so they hold for every session in the pool
+		if cfg.StatementTimeout > 0 {
+			dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout.Milliseconds())
+		}
+		if cfg.LockTimeout > 0 {
+			dsn += fmt.Sprintf(" lock_timeout=%d", cfg.LockTimeout.Milliseconds())
+		}
+	case "mysql":
+		charset, loc := cfg.Charset, cfg.Loc
+		if charset == "" {
+			charset = "utf8mb4"
+		}
+		if loc == "" {
+			loc = "Local"
+		}
+		dsn = fmt.Sprintf(
+			"%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=true&loc=%s",
+			cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, charset, url.QueryEscape(loc),
+		)
 	case "sqlite":
-		dialector = sqlite.Open(cfg.Database)
+		dsn = cfg.Database
 	default:
-		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+		return nil, fmt.Errorf("unsupported database driver: %s (supported: postgres, mysql, sqlite)", cfg.Driver)
 	}
 
-	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+	db, err := gorm.Open(open(cfg.Driver, dsn), &gorm.Config{
+		Logger: d.queryLog,
+		// Map driver errors such as unique violations to gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &Database{db: db}, nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection pool: %w", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	// database/sql keeps 2 idle connections unless told otherwise
+	maxIdleConns := 2
+	if cfg.MaxIdleConns > 0 {
+		maxIdleConns = cfg.MaxIdleConns
+		sqlDB.SetMaxIdleConns(maxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if len(cfg.Replicas) > 0 {
+		replicas := make([]gorm.Dialector, len(cfg.Replicas))
+		for i, replicaDSN := range cfg.Replicas {
+			replicas[i] = open(cfg.Driver, replicaDSN)
+		}
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		})
+		if cfg.MaxOpenConns > 0 {
+			resolver.SetMaxOpenConns(cfg.MaxOpenConns)
+		}
+		if cfg.MaxIdleConns > 0 {
+			resolver.SetMaxIdleConns(cfg.MaxIdleConns)
+		}
+		if cfg.ConnMaxLifetime > 0 {
+			resolver.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		}
+		if cfg.ConnMaxIdleTime > 0 {
+			resolver.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		}
+		if err := db.Use(resolver); err != nil {
+			return nil, fmt.Errorf("failed to register read replicas: %w", err)
+		}
+	}
+
+	d.db, d.maxIdleConns = db, maxIdleConns
+
+	if err := d.registerTiming(); err != nil {
+		return nil, fmt.Errorf("failed to register timing: %w", err)
+	}
+	if d.tenant != nil {
+		if err := d.registerTenantScope(); err != nil {
+			return nil, fmt.Errorf("failed to register tenant scope: %w", err)
+		}
+	}
+	if d.reconnectInterval > 0 {
+		if err := d.registerAvailabilityCheck(); err != nil {
+			return nil, fmt.Errorf("failed to register availability check: %w", err)
+		}
+		go d.monitor()
+	}
+
+	return d, nil
+}
+
+// open returns the dialector for driver, which New has already validated
+func open(driver, dsn string) gorm.Dialector {
+	switch driver {
+	case "postgres":
+		return postgres.Open(dsn)
+	case "mysql":
+		return mysql.Open(dsn)
+	default:
+		return sqlite.Open(dsn)
+	}
 }
 
 // DB returns the underlying gorm.DB
@@ -58,8 +241,59 @@ func (d *Database) DB() *gorm.DB {
 	return d.db
 }
 
+// WithTransaction runs fn in a transaction bound to the context passed to
+// it. Repositories using Conn join the transaction automatically; it is
+// committed when fn returns nil and rolled back otherwise. Nested calls
+// use savepoints. See WithTxRetry for retrying conflicting transactions.
+func (d *Database) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Begin bypasses GORM callbacks, so check availability here
+	if err := d.unavailable(); err != nil {
+		return err
+	}
+
+	run := func() error { return d.transaction(ctx, fn) }
+	// A failed savepoint aborts the enclosing transaction, so only the
+	// outermost call can retry
+	if _, nested := ctx.Value(txKey{}).(*gorm.DB); nested || d.txRetry.MaxAttempts <= 1 {
+		return run()
+	}
+	return retry.Do(ctx, d.txRetry, run)
+}
+
+// WithTransactionOnce is WithTransaction without retries, for work that
+// cannot be replayed, such as a request handler
+func (d *Database) WithTransactionOnce(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := d.unavailable(); err != nil {
+		return err
+	}
+	return d.transaction(ctx, fn)
+}
+
+func (d *Database) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// Conn returns the transaction bound to ctx if any, otherwise db, scoped to ctx
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction reports whether ctx carries a transaction started by
+// WithTransaction
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
+	d.stopOnce.Do(func() { close(d.stop) })
+
 	sqlDB, err := d.db.DB()
 	if err != nil {
 		return err
@@ -67,7 +301,27 @@ func (d *Database) Close() error {
 	return sqlDB.Close()
 }
 
-// AutoMigrate runs auto migration for given models
+// Ping verifies a connection to the database is still alive
+func (d *Database) Ping(ctx context.Context) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Check implements health.Checker
+func (d *Database) Check(ctx context.Context) error {
+	d.mu.RLock()
+	downErr := d.downErr
+	d.mu.RUnlock()
+	if downErr != nil {
+		return fmt.Errorf("database unavailable: %w", downErr)
+	}
+	return d.Ping(ctx)
+}
+
+// AutoMigrate runs auto migration for given models on the primary
 func (d *Database) AutoMigrate(models ...interface{}) error {
-	return d.db.AutoMigrate(models...)
+	return d.db.Clauses(dbresolver.Write).AutoMigrate(models...)
 }
@@ -0,0 +1,182 @@
+// pkg/database/encryption.go
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// EncryptedSerializer is the GORM serializer that encrypts a string or
+// []byte field at rest with AES-GCM. Tag a field to use it:
+//
+//	SSN string `json:"-" gorm:"serializer:encrypted"`
+//
+// Values are stored as "<key version>:<base64 nonce and ciphertext>", so
+// the column must be a text type. Each write uses a fresh nonce, so
+// encrypted columns cannot be searched or indexed by value.
+const EncryptedSerializer = "encrypted"
+
+// ErrNoEncryptionKey is returned when an encrypted field is read or
+// written before SetEncryptionKeys installed a key
+var ErrNoEncryptionKey = errors.New("no encryption key configured")
+
+func init() {
+	schema.RegisterSerializer(EncryptedSerializer, encryptedSerializer{})
+}
+
+// keyring holds the ciphers by key version; current encrypts new values
+type keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// encryptionKeys is set by SetEncryptionKeys, nil until then
+var encryptionKeys *keyring
+
+// SetEncryptionKeys installs the keys for EncryptedSerializer fields. Each
+// entry is "<version>:<base64 key>", where the key is 16, 24 or 32 bytes
+// for AES-128, -192 or -256. The first entry encrypts writes; every entry
+// decrypts, so to rotate, put a new key first and keep the old ones until
+// all rows have been rewritten. Call it once at startup.
+func SetEncryptionKeys(keys []string) error {
+	if len(keys) == 0 {
+		encryptionKeys = nil
+		return nil
+	}
+
+	ring := &keyring{aeads: make(map[string]cipher.AEAD, len(keys))}
+	for i, entry := range keys {
+		version, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || version == "" {
+			return fmt.Errorf("encryption key %d: want <version>:<base64 key>", i)
+		}
+		if _, dup := ring.aeads[version]; dup {
+			return fmt.Errorf("encryption key version %q listed twice", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("encryption key %q: %w", version, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf("encryption key %q: %w", version, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return fmt.Errorf("encryption key %q: %w", version, err)
+		}
+		ring.aeads[version] = aead
+		if i == 0 {
+			ring.current = version
+		}
+	}
+	encryptionKeys = ring
+	return nil
+}
+
+// encrypt seals plaintext with the current key. The version is bound as
+// additional data so a value cannot be relabeled with another key.
+func (k *keyring) encrypt(plaintext []byte) (string, error) {
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(k.current))
+	return k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a value produced by encrypt with the key it names
+func (k *keyring) decrypt(value string) ([]byte, error) {
+	version, encoded, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, errors.New("encrypted value has no key version")
+	}
+	aead, ok := k.aeads[version]
+	if !ok {
+		return nil, fmt.Errorf("encrypted value uses unknown key version %q", version)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted value is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(version))
+}
+
+// encryptedSerializer implements EncryptedSerializer
+type encryptedSerializer struct{}
+
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	if dbValue != nil {
+		var value string
+		switch v := dbValue.(type) {
+		case []byte:
+			value = string(v)
+		case string:
+			value = v
+		default:
+			return fmt.Errorf("failed to decrypt %s: unexpected value %T", field.Name, dbValue)
+		}
+
+		ring := encryptionKeys
+		if ring == nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, ErrNoEncryptionKey)
+		}
+		plaintext, err := ring.decrypt(value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+
+		switch t := field.FieldType; {
+		case t.Kind() == reflect.String:
+			fieldValue.Elem().SetString(string(plaintext))
+		case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+			fieldValue.Elem().SetBytes(plaintext)
+		default:
+			return fmt.Errorf("failed to decrypt %s: unsupported field type %s", field.Name, field.FieldType)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (encryptedSerializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext []byte
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = []byte(v)
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = v
+	default:
+		return nil, fmt.Errorf("failed to encrypt %s: unsupported field type %T", field.Name, fieldValue)
+	}
+
+	ring := encryptionKeys
+	if ring == nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", field.Name, ErrNoEncryptionKey)
+	}
+	value, err := ring.encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", field.Name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,104 @@
+// pkg/database/encryption_test.go
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+type secretRecord struct {
+	ID    uint
+	Name  string
+	SSN   string `gorm:"serializer:encrypted"`
+	Notes []byte `gorm:"serializer:encrypted"`
+}
+
+func testKey(version string, b byte) string {
+	return version + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+// rawSSN reads the stored column, bypassing the serializer
+func rawSSN(t *testing.T, db *Database, id uint) string {
+	t.Helper()
+
+	var raw string
+	if err := db.DB().Raw("SELECT ssn FROM secret_records WHERE id = ?", id).Scan(&raw).Error; err != nil {
+		t.Fatalf("read raw column: %v", err)
+	}
+	return raw
+}
+
+func TestEncryptedSerializer(t *testing.T) {
+	t.Cleanup(func() { _ = SetEncryptionKeys(nil) })
+	if err := SetEncryptionKeys([]string{testKey("v1", 1)}); err != nil {
+		t.Fatalf("SetEncryptionKeys: %v", err)
+	}
+
+	db := newTestDatabase(t, 1)
+	ctx := context.Background()
+	if err := db.DB().AutoMigrate(&secretRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	rec := secretRecord{Name: "plain", SSN: "123-45-6789", Notes: []byte("note")}
+	if err := db.DB().WithContext(ctx).Create(&rec).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	raw := rawSSN(t, db, rec.ID)
+	if !strings.HasPrefix(raw, "v1:") || strings.Contains(raw, "123-45-6789") {
+		t.Errorf("stored ssn = %q, want ciphertext tagged v1", raw)
+	}
+
+	var got secretRecord
+	if err := db.DB().First(&got, rec.ID).Error; err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.SSN != rec.SSN || string(got.Notes) != "note" || got.Name != "plain" {
+		t.Errorf("loaded %+v, want %+v", got, rec)
+	}
+
+	// Rotate: the old key still decrypts, and rewrites use the new one
+	if err := SetEncryptionKeys([]string{testKey("v2", 2), testKey("v1", 1)}); err != nil {
+		t.Fatalf("SetEncryptionKeys: %v", err)
+	}
+	got = secretRecord{}
+	if err := db.DB().First(&got, rec.ID).Error; err != nil {
+		t.Fatalf("load after rotation: %v", err)
+	}
+	if got.SSN != rec.SSN {
+		t.Errorf("ssn after rotation = %q, want %q", got.SSN, rec.SSN)
+	}
+	if err := db.DB().Save(&got).Error; err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if raw := rawSSN(t, db, rec.ID); !strings.HasPrefix(raw, "v2:") {
+		t.Errorf("rewritten ssn = %q, want ciphertext tagged v2", raw)
+	}
+
+	// Dropping the key a value was written with makes it unreadable
+	if err := SetEncryptionKeys([]string{testKey("v3", 3)}); err != nil {
+		t.Fatalf("SetEncryptionKeys: %v", err)
+	}
+	if err := db.DB().First(&secretRecord{}, rec.ID).Error; err == nil {
+		t.Error("loaded a value encrypted with a removed key")
+	}
+}
+
+func TestSetEncryptionKeysRejectsBadKeys(t *testing.T) {
+	t.Cleanup(func() { _ = SetEncryptionKeys(nil) })
+
+	for _, keys := range [][]string{
+		{"no-version"},
+		{"v1:not-base64!"},
+		{"v1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{testKey("v1", 1), testKey("v1", 2)},
+	} {
+		if err := SetEncryptionKeys(keys); err == nil {
+			t.Errorf("SetEncryptionKeys(%q) succeeded", keys)
+		}
+	}
+}
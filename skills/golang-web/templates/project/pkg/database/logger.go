@@ -0,0 +1,83 @@
+// pkg/database/logger.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// WithQueryLog routes GORM's logging through l. Queries slower than
+// slowThreshold are logged at warn with their SQL and duration; when l has
+// debug enabled every query is logged at debug. Zero disables slow query
+// logging. Without this option the database logs nothing.
+func WithQueryLog(l *slog.Logger, slowThreshold time.Duration) Option {
+	return func(d *Database) {
+		d.queryLog = &queryLogger{logger: l, slowThreshold: slowThreshold}
+	}
+}
+
+// queryLogger adapts slog to logger.Interface. Levels are decided by the
+// slog handler, so they follow runtime log level changes; GORM's own log
+// mode is ignored.
+type queryLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+}
+
+func (l *queryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+	if !slow && !l.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []interface{}{
+		"sql", sql,
+		"duration", elapsed,
+		"rows", rows,
+		"source", utils.FileWithLineNum(),
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		attrs = append(attrs, "error", err)
+	}
+
+	if slow {
+		l.logger.WarnContext(ctx, "slow query", append(attrs, "threshold", l.slowThreshold)...)
+		return
+	}
+	l.logger.DebugContext(ctx, "query", attrs...)
+}
+
+// ParamsFilter keeps bound values such as password hashes out of the SQL
+// unless debug logging is enabled
+func (l *queryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.logger.Enabled(ctx, slog.LevelDebug) {
+		return sql, params
+	}
+	return sql, nil
+}
@@ -0,0 +1,94 @@
+// pkg/database/reconnect.go
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourname/myapp/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// monitor pings the database every reconnectInterval until Close, tracking
+// whether the connection is lost
+func (d *Database) monitor() {
+	ticker := time.NewTicker(d.reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), d.reconnectInterval)
+		err := d.Ping(ctx)
+		cancel()
+
+		d.mu.Lock()
+		wasDown := d.downErr != nil
+		d.downErr = err
+		d.mu.Unlock()
+
+		switch {
+		case err != nil && !wasDown:
+			slog.Error("database connection lost", "error", err)
+			d.dropIdleConns()
+		case err == nil && wasDown:
+			slog.Info("database connection restored")
+		}
+	}
+}
+
+// dropIdleConns closes pooled connections that are likely dead so the pool
+// dials fresh ones once the database is back
+func (d *Database) dropIdleConns() {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return
+	}
+	sqlDB.SetMaxIdleConns(0)
+	sqlDB.SetMaxIdleConns(d.maxIdleConns)
+}
+
+// unavailable returns errors.ErrUnavailable while the connection is lost
+func (d *Database) unavailable() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.downErr != nil {
+		return errors.ErrUnavailable
+	}
+	return nil
+}
+
+// registerAvailabilityCheck makes every GORM operation fail fast while the
+// connection is lost, instead of waiting on a dead pool
+func (d *Database) registerAvailabilityCheck() error {
+	check := func(tx *gorm.DB) {
+		if err := d.unavailable(); err != nil {
+			_ = tx.AddError(err)
+		}
+	}
+
+	const name = "myapp:availability"
+	cb := d.db.Callback()
+	if err := cb.Create().Before("*").Register(name, check); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register(name, check); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register(name, check); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register(name, check); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register(name, check); err != nil {
+		return err
+	}
+	return cb.Raw().Before("*").Register(name, check)
+}
@@ -0,0 +1,45 @@
+// pkg/database/retry.go
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/yourname/myapp/pkg/retry"
+)
+
+// WithTxRetry reruns a whole WithTransaction call when it fails with an
+// error p.Retryable accepts, IsSerializationFailure if nil. The database
+// aborts one side of a conflict between concurrent transactions, and the
+// only remedy is to start over, so fn may run several times and must not
+// have effects outside the transaction. Nested calls are not retried on
+// their own; the outermost one is.
+func WithTxRetry(p retry.Policy) Option {
+	return func(d *Database) {
+		if p.Retryable == nil {
+			p.Retryable = IsSerializationFailure
+		}
+		d.txRetry = p
+	}
+}
+
+// IsSerializationFailure reports whether err means the transaction lost a
+// conflict and may succeed if retried: a Postgres serialization failure
+// (SQLSTATE 40001) or deadlock (40P01), or a MySQL deadlock (1213).
+// SQLite serializes writers and does not report either.
+func IsSerializationFailure(err error) bool {
+	// Implemented by *pgconn.PgError
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		switch pgErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1213
+	}
+	return false
+}
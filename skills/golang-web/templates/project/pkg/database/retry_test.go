@@ -0,0 +1,152 @@
+// pkg/database/retry_test.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/yourname/myapp/pkg/retry"
+)
+
+// sqlStateError mimics *pgconn.PgError
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func newTestDatabase(t *testing.T, maxAttempts int) *Database {
+	t.Helper()
+
+	db, err := New(Config{Driver: "sqlite", Database: filepath.Join(t.TempDir(), "test.db")},
+		WithTxRetry(retry.Policy{MaxAttempts: maxAttempts}),
+	)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.DB().Exec("CREATE TABLE items (name TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	return db
+}
+
+func countItems(t *testing.T, db *Database) int64 {
+	t.Helper()
+
+	var n int64
+	if err := db.DB().Table("items").Count(&n).Error; err != nil {
+		t.Fatalf("count items: %v", err)
+	}
+	return n
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"postgres serialization failure", sqlStateError("40001"), true},
+		{"postgres deadlock", sqlStateError("40P01"), true},
+		{"wrapped", fmt.Errorf("commit: %w", sqlStateError("40001")), true},
+		{"postgres unique violation", sqlStateError("23505"), false},
+		{"mysql deadlock", &mysql.MySQLError{Number: 1213}, true},
+		{"mysql duplicate entry", &mysql.MySQLError{Number: 1062}, false},
+		{"other", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSerializationFailure(tt.err); got != tt.want {
+				t.Errorf("IsSerializationFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTransactionRetriesSerializationFailures(t *testing.T) {
+	db := newTestDatabase(t, 3)
+
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		if err := Conn(ctx, db.DB()).Exec("INSERT INTO items (name) VALUES (?)", "a").Error; err != nil {
+			return err
+		}
+		if calls < 3 {
+			return sqlStateError("40001")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn ran %d times, want 3", calls)
+	}
+	// Failed attempts are rolled back
+	if n := countItems(t, db); n != 1 {
+		t.Errorf("items = %d, want 1", n)
+	}
+}
+
+func TestWithTransactionGivesUpAfterMaxAttempts(t *testing.T) {
+	db := newTestDatabase(t, 3)
+
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		return sqlStateError("40001")
+	})
+	if !IsSerializationFailure(err) {
+		t.Fatalf("WithTransaction error = %v, want the serialization failure", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn ran %d times, want 3", calls)
+	}
+}
+
+func TestWithTransactionDoesNotRetryOtherErrors(t *testing.T) {
+	db := newTestDatabase(t, 3)
+	errBoom := errors.New("boom")
+
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("WithTransaction error = %v, want %v", err, errBoom)
+	}
+	if calls != 1 {
+		t.Errorf("fn ran %d times, want 1", calls)
+	}
+}
+
+func TestWithTransactionRetriesOnlyOutermost(t *testing.T) {
+	db := newTestDatabase(t, 3)
+
+	outer, inner := 0, 0
+	err := db.WithTransaction(context.Background(), func(ctx context.Context) error {
+		outer++
+		return db.WithTransaction(ctx, func(ctx context.Context) error {
+			inner++
+			if inner == 1 {
+				return sqlStateError("40P01")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if outer != 2 || inner != 2 {
+		t.Errorf("outer ran %d times and inner %d, want 2 each", outer, inner)
+	}
+}
@@ -0,0 +1,76 @@
+// pkg/database/tenant.go
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantColumn is the column WithTenantScope matches against the tenant
+const TenantColumn = "tenant_id"
+
+// ErrTenantUpsert is returned for upserts into a tenant-scoped table, whose
+// conflicting row may belong to another tenant. gorm's Save falls back to
+// one when an update matches no row, so create such rows explicitly.
+var ErrTenantUpsert = errors.New("database: upsert into a tenant-scoped table")
+
+// WithTenantScope confines every statement on a model with a tenant_id
+// column to the tenant that tenant returns for the statement's context:
+// queries, updates and deletes, Unscoped ones included, match only that
+// tenant's rows, and inserts have the column set to it. Raw SQL, and
+// statements naming a table rather than a model, are left as written.
+func WithTenantScope(tenant func(context.Context) string) Option {
+	return func(d *Database) {
+		d.tenant = tenant
+	}
+}
+
+// registerTenantScope installs the callbacks behind WithTenantScope
+func (d *Database) registerTenantScope() error {
+	where := func(tx *gorm.DB) {
+		if !tenantScoped(tx) {
+			return
+		}
+		tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: TenantColumn},
+			Value:  d.tenant(tx.Statement.Context),
+		}}})
+	}
+	set := func(tx *gorm.DB) {
+		if !tenantScoped(tx) {
+			return
+		}
+		if c, ok := tx.Statement.Clauses["ON CONFLICT"]; ok {
+			if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+				_ = tx.AddError(ErrTenantUpsert)
+				return
+			}
+		}
+		tx.Statement.SetColumn(TenantColumn, d.tenant(tx.Statement.Context), true)
+	}
+
+	const name = "myapp:tenant"
+	cb := d.db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(name, set); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register(name, where); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(name, where); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register(name, where); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register(name, where)
+}
+
+// tenantScoped reports whether the statement's model has a tenant column
+func tenantScoped(tx *gorm.DB) bool {
+	return tx.Error == nil && tx.Statement.Schema != nil &&
+		tx.Statement.Schema.LookUpField(TenantColumn) != nil
+}
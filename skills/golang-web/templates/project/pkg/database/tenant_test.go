@@ -0,0 +1,72 @@
+// pkg/database/tenant_test.go
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourname/myapp/pkg/ctxkeys"
+)
+
+type tenantRecord struct {
+	ID       uint
+	TenantID string
+	Name     string
+}
+
+func TestTenantScope(t *testing.T) {
+	db, err := New(Config{Driver: "sqlite", Database: filepath.Join(t.TempDir(), "test.db")},
+		WithTenantScope(ctxkeys.TenantID),
+	)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.DB().AutoMigrate(&tenantRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	ctxA := ctxkeys.WithTenantID(context.Background(), "acme")
+	ctxB := ctxkeys.WithTenantID(context.Background(), "globex")
+
+	// The context decides the tenant, not the record
+	rec := tenantRecord{TenantID: "globex", Name: "a1"}
+	if err := db.DB().WithContext(ctxA).Create(&rec).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if rec.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", rec.TenantID)
+	}
+	batch := []tenantRecord{{Name: "b1"}, {Name: "b2"}}
+	if err := db.DB().WithContext(ctxB).Create(&batch).Error; err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	var n int64
+	if err := db.DB().WithContext(ctxB).Model(&tenantRecord{}).Unscoped().
+		Where("name = ? OR name = ?", "a1", "b1").Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("count across an OR = %d, want 1", n)
+	}
+
+	result := db.DB().WithContext(ctxB).Model(&tenantRecord{}).Where("id = ?", rec.ID).Update("name", "stolen")
+	if result.Error != nil || result.RowsAffected != 0 {
+		t.Errorf("cross-tenant update affected %d rows (%v), want 0", result.RowsAffected, result.Error)
+	}
+	if err := db.DB().WithContext(ctxB).Delete(&tenantRecord{}, rec.ID).Error; err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	var got tenantRecord
+	if err := db.DB().WithContext(ctxA).First(&got, rec.ID).Error; err != nil || got.Name != "a1" {
+		t.Errorf("acme's record after globex wrote to it = %+v (%v), want it untouched", got, err)
+	}
+
+	// Save falls back to an upsert when no row of the tenant matches
+	if err := db.DB().WithContext(ctxB).Save(&tenantRecord{ID: rec.ID, Name: "stolen"}).Error; !errors.Is(err, ErrTenantUpsert) {
+		t.Errorf("cross-tenant Save = %v, want ErrTenantUpsert", err)
+	}
+}
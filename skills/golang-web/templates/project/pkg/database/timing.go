@@ -0,0 +1,64 @@
+// pkg/database/timing.go
+package database
+
+import (
+	"time"
+
+	"github.com/yourname/myapp/pkg/timing"
+	"gorm.io/gorm"
+)
+
+// timingStartKey holds when the current statement started
+const timingStartKey = "myapp:timing_started"
+
+// registerTiming records the duration of every statement as the db phase
+// of the timing.Recorder in its context, if any
+func (d *Database) registerTiming() error {
+	start := func(tx *gorm.DB) {
+		if timing.FromContext(tx.Statement.Context) != nil {
+			tx.InstanceSet(timingStartKey, time.Now())
+		}
+	}
+	stop := func(tx *gorm.DB) {
+		if t, ok := tx.InstanceGet(timingStartKey); ok {
+			timing.Add(tx.Statement.Context, "db", time.Since(t.(time.Time)))
+		}
+	}
+
+	const before, after = "myapp:timing_start", "myapp:timing_stop"
+	cb := d.db.Callback()
+	if err := cb.Create().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	if err := cb.Create().After("*").Register(after, stop); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register(after, stop); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register(after, stop); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("*").Register(after, stop); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	if err := cb.Row().After("*").Register(after, stop); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("*").Register(before, start); err != nil {
+		return err
+	}
+	return cb.Raw().After("*").Register(after, stop)
+}
@@ -0,0 +1,24 @@
+// pkg/errors/context.go
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// FromContext maps a context error anywhere in err's chain, however it was
+// wrapped, to the error the client should see: KindCanceled when the
+// request was canceled, usually because the client disconnected, and
+// KindTimeout when its deadline passed. It returns nil if err holds no
+// context error.
+func FromContext(err error) *AppError {
+	switch {
+	case err == nil:
+		return nil
+	case stderrors.Is(err, context.Canceled):
+		return build(KindCanceled, ErrCanceled.Message, err)
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return build(KindTimeout, ErrTimeout.Message, err)
+	}
+	return nil
+}
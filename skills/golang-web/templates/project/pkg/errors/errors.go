@@ -1,13 +1,120 @@
 // pkg/errors/errors.go
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// CaptureStackTrace controls whether New and Wrap record the call stack.
+// Set it once at startup, e.g. to false if capture shows up in profiles.
+var CaptureStackTrace = true
+
+const maxStackDepth = 32
+
+// StatusClientClosedRequest is the non-standard status, from nginx, for a
+// request whose client went away before the response was ready
+const StatusClientClosedRequest = 499
+
+// Kind classifies an error and determines its HTTP status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+	KindTimeout
+	KindTooLarge
+	KindUnavailable
+	KindNotAcceptable
+	KindUnsupportedMediaType
+	KindCanceled
+	KindUnprocessable
+)
+
+// HTTPStatus returns the HTTP status code for this kind
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindValidation:
+		return 400
+	case KindUnauthorized:
+		return 401
+	case KindForbidden:
+		return 403
+	case KindNotFound:
+		return 404
+	case KindConflict:
+		return 409
+	case KindTooManyRequests:
+		return 429
+	case KindTimeout:
+		return 504
+	case KindTooLarge:
+		return 413
+	case KindUnavailable:
+		return 503
+	case KindNotAcceptable:
+		return 406
+	case KindUnsupportedMediaType:
+		return 415
+	case KindCanceled:
+		return StatusClientClosedRequest
+	case KindUnprocessable:
+		return 422
+	default:
+		return 500
+	}
+}
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindTooManyRequests:
+		return "too_many_requests"
+	case KindTimeout:
+		return "timeout"
+	case KindTooLarge:
+		return "too_large"
+	case KindUnavailable:
+		return "unavailable"
+	case KindNotAcceptable:
+		return "not_acceptable"
+	case KindUnsupportedMediaType:
+		return "unsupported_media_type"
+	case KindCanceled:
+		return "canceled"
+	case KindUnprocessable:
+		return "unprocessable"
+	default:
+		return "internal"
+	}
+}
 
-// AppError represents an application error with code and message
+// AppError represents an application error with kind, code and message.
+// Code is reported to clients and defaults to the kind's HTTP status.
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Cause   error  `json:"-"`
+	Kind    Kind         `json:"-"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Cause   error        `json:"-"`
+	Details []FieldError `json:"details,omitempty"`
+	stack   []uintptr
+	origin  *AppError // the sentinel WithStack copied, if any
 }
 
 func (e *AppError) Error() string {
@@ -21,63 +128,209 @@ func (e *AppError) Unwrap() error {
 	return e.Cause
 }
 
+// Is reports whether target is the sentinel e was copied from
+func (e *AppError) Is(target error) bool {
+	return e.origin != nil && target == e.origin
+}
+
+// StackTrace returns the program counters captured when the error was
+// created, or nil for sentinels and when capture is disabled
+func (e *AppError) StackTrace() []uintptr {
+	return e.stack
+}
+
+// WithStack returns a copy of e carrying the stack of its caller, for
+// sentinels returned as they are. The copy still matches e under Is.
+func (e *AppError) WithStack() *AppError {
+	c := *e
+	c.stack = callers(1)
+	if c.origin == nil {
+		c.origin = e
+	}
+	return &c
+}
+
+// Format prints the stack trace after the message when formatted with %+v
+func (e *AppError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		_, _ = io.WriteString(s, e.Error())
+		if s.Flag('+') && len(e.stack) > 0 {
+			frames := runtime.CallersFrames(e.stack)
+			for {
+				f, more := frames.Next()
+				fmt.Fprintf(s, "\n%s\n\t%s:%d", f.Function, f.File, f.Line)
+				if !more {
+					break
+				}
+			}
+		}
+	case 's':
+		_, _ = io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
 // HTTPStatus returns the HTTP status code for this error
 func (e *AppError) HTTPStatus() int {
-	switch {
-	case e.Code >= 500:
-		return 500
-	case e.Code >= 400:
-		return e.Code
-	default:
-		return 500
-	}
+	return e.Kind.HTTPStatus()
 }
 
 // New creates a new AppError
-func New(code int, message string) *AppError {
-	return &AppError{
-		Code:    code,
-		Message: message,
-	}
+func New(kind Kind, message string) *AppError {
+	return build(kind, message, nil)
 }
 
 // Wrap wraps an existing error with additional context
-func Wrap(err error, code int, message string) *AppError {
+func Wrap(err error, kind Kind, message string) *AppError {
 	if err == nil {
 		return nil
 	}
-	return &AppError{
-		Code:    code,
-		Message: message,
-		Cause:   err,
-	}
+	return build(kind, message, err)
 }
 
 // Wrapf wraps an error with formatted message
-func Wrapf(err error, code int, format string, args ...interface{}) *AppError {
+func Wrapf(err error, kind Kind, format string, args ...interface{}) *AppError {
 	if err == nil {
 		return nil
 	}
+	return build(kind, fmt.Sprintf(format, args...), err)
+}
+
+// Validation creates a KindValidation error
+func Validation(message string) *AppError {
+	return build(KindValidation, message, nil)
+}
+
+// Unauthorized creates a KindUnauthorized error
+func Unauthorized(message string) *AppError {
+	return build(KindUnauthorized, message, nil)
+}
+
+// Forbidden creates a KindForbidden error
+func Forbidden(message string) *AppError {
+	return build(KindForbidden, message, nil)
+}
+
+// NotFound creates a KindNotFound error
+func NotFound(message string) *AppError {
+	return build(KindNotFound, message, nil)
+}
+
+// Conflict creates a KindConflict error
+func Conflict(message string) *AppError {
+	return build(KindConflict, message, nil)
+}
+
+// TooManyRequests creates a KindTooManyRequests error
+func TooManyRequests(message string) *AppError {
+	return build(KindTooManyRequests, message, nil)
+}
+
+// Timeout creates a KindTimeout error
+func Timeout(message string) *AppError {
+	return build(KindTimeout, message, nil)
+}
+
+// TooLarge creates a KindTooLarge error
+func TooLarge(message string) *AppError {
+	return build(KindTooLarge, message, nil)
+}
+
+// Unavailable creates a KindUnavailable error
+func Unavailable(message string) *AppError {
+	return build(KindUnavailable, message, nil)
+}
+
+// NotAcceptable creates a KindNotAcceptable error
+func NotAcceptable(message string) *AppError {
+	return build(KindNotAcceptable, message, nil)
+}
+
+// UnsupportedMediaType creates a KindUnsupportedMediaType error
+func UnsupportedMediaType(message string) *AppError {
+	return build(KindUnsupportedMediaType, message, nil)
+}
+
+// Canceled creates a KindCanceled error
+func Canceled(message string) *AppError {
+	return build(KindCanceled, message, nil)
+}
+
+// Unprocessable creates a KindUnprocessable error
+func Unprocessable(message string) *AppError {
+	return build(KindUnprocessable, message, nil)
+}
+
+// Internal creates a KindInternal error
+func Internal(message string) *AppError {
+	return build(KindInternal, message, nil)
+}
+
+// Is reports whether any error in err's chain matches target
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// build must be called directly by an exported constructor so the captured
+// stack starts at the constructor's caller
+func build(kind Kind, message string, cause error) *AppError {
 	return &AppError{
-		Code:    code,
-		Message: fmt.Sprintf(format, args...),
-		Cause:   err,
+		Kind:    kind,
+		Code:    kind.HTTPStatus(),
+		Message: message,
+		Cause:   cause,
+		stack:   callers(2),
+	}
+}
+
+// Sentinel creates an error for a package-level variable. It has no stack,
+// which would only point at package initialization; Wrap or WithStack
+// records one where the error happens.
+func Sentinel(kind Kind, message string) *AppError {
+	return &AppError{Kind: kind, Code: kind.HTTPStatus(), Message: message}
+}
+
+// callers captures the stack above the caller of callers, skipping skip
+// more frames
+func callers(skip int) []uintptr {
+	if !CaptureStackTrace {
+		return nil
 	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
 }
 
 // Predefined errors
 var (
-	ErrInternal      = New(500, "internal server error")
-	ErrInvalidParams = New(400, "invalid parameters")
-	ErrNotFound      = New(404, "resource not found")
-	ErrUnauthorized  = New(401, "unauthorized")
-	ErrForbidden     = New(403, "forbidden")
-	ErrConflict      = New(409, "resource already exists")
+	ErrInternal      = Sentinel(KindInternal, "internal server error")
+	ErrInvalidParams = Sentinel(KindValidation, "invalid parameters")
+	ErrNotFound      = Sentinel(KindNotFound, "resource not found")
+	ErrUnauthorized  = Sentinel(KindUnauthorized, "unauthorized")
+	ErrForbidden     = Sentinel(KindForbidden, "forbidden")
+	ErrConflict      = Sentinel(KindConflict, "resource already exists")
+
+	ErrTooManyRequests = Sentinel(KindTooManyRequests, "too many requests")
+	ErrTimeout         = Sentinel(KindTimeout, "request timed out")
+	ErrCanceled        = Sentinel(KindCanceled, "client closed request")
+	ErrUnavailable     = Sentinel(KindUnavailable, "service temporarily unavailable")
+	ErrMaintenance     = Sentinel(KindUnavailable, "service is under maintenance")
 )
 
 // Specific errors
 var (
-	ErrUserNotFound = New(404, "user not found")
-	ErrUserExists   = New(409, "user already exists")
-	ErrInvalidToken = New(401, "invalid token")
+	ErrUserNotFound = Sentinel(KindNotFound, "user not found")
+	ErrUserExists   = Sentinel(KindConflict, "user already exists")
+
+	ErrVersionConflict = Sentinel(KindConflict, "resource was modified by another request")
+	ErrInvalidToken    = Sentinel(KindUnauthorized, "invalid token")
+
+	ErrAccountLocked = Sentinel(KindForbidden, "too many failed login attempts, try again later")
 )
@@ -0,0 +1,36 @@
+// pkg/errors/errors_test.go
+package errors
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// firstFunction names the function of the first frame of err's stack
+func firstFunction(err *AppError) string {
+	frame, _ := runtime.CallersFrames(err.StackTrace()).Next()
+	return frame.Function
+}
+
+func TestStackTrace(t *testing.T) {
+	if ErrUserNotFound.StackTrace() != nil {
+		t.Error("sentinel has a stack from package initialization")
+	}
+
+	wrapped := Wrap(ErrUserNotFound, KindNotFound, "load user")
+	if fn := firstFunction(wrapped); !strings.HasSuffix(fn, ".TestStackTrace") {
+		t.Errorf("Wrap stack starts in %s, want TestStackTrace", fn)
+	}
+
+	traced := ErrUserNotFound.WithStack()
+	if fn := firstFunction(traced); !strings.HasSuffix(fn, ".TestStackTrace") {
+		t.Errorf("WithStack stack starts in %s, want TestStackTrace", fn)
+	}
+	if !Is(traced, ErrUserNotFound) || Is(traced, ErrNotFound) {
+		t.Error("WithStack copy does not match only its sentinel")
+	}
+	if !Is(traced.WithStack(), ErrUserNotFound) {
+		t.Error("WithStack of a copy does not match the sentinel")
+	}
+}
@@ -0,0 +1,95 @@
+// pkg/errors/validation.go
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// FromBinding converts a request binding error into a validation AppError,
+// with per-field details when the cause is a validation or type error. A
+// body cut off by http.MaxBytesReader yields a KindTooLarge error instead.
+func FromBinding(err error) *AppError {
+	var mbe *http.MaxBytesError
+	if stderrors.As(err, &mbe) {
+		return build(KindTooLarge, fmt.Sprintf("request body exceeds %d bytes", mbe.Limit), err)
+	}
+
+	appErr := build(KindValidation, ErrInvalidParams.Message, err)
+
+	var ve validator.ValidationErrors
+	var ute *json.UnmarshalTypeError
+	switch {
+	case stderrors.As(err, &ve):
+		appErr.Details = make([]FieldError, 0, len(ve))
+		for _, fe := range ve {
+			appErr.Details = append(appErr.Details, FieldError{
+				Field:   fe.Field(),
+				Tag:     fe.Tag(),
+				Message: fieldMessage(fe),
+			})
+		}
+	case stderrors.As(err, &ute):
+		appErr.Details = []FieldError{{
+			Field:   ute.Field,
+			Tag:     "type",
+			Message: fmt.Sprintf("%s must be of type %s", ute.Field, ute.Type),
+		}}
+	}
+	return appErr
+}
+
+// InvalidParams creates a validation AppError like FromBinding's, for
+// parameters checked by hand rather than through binding tags
+func InvalidParams(details ...FieldError) *AppError {
+	appErr := build(KindValidation, ErrInvalidParams.Message, nil)
+	appErr.Details = details
+	return appErr
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fe.Field())
+	case "min":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s characters", fe.Field(), fe.Param())
+	case "max":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s characters", fe.Field(), fe.Param())
+	case "maxbytes":
+		return fmt.Sprintf("%s must be at most %s bytes", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	default:
+		return fmt.Sprintf("%s failed %s validation", fe.Field(), fe.Tag())
+	}
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+// pkg/health/health.go
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrShuttingDown is reported by readiness checks once shutdown has begun
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Checker reports whether a dependency is able to serve traffic
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Probe aggregates checkers into a readiness signal
+type Probe struct {
+	checkers     []Checker
+	shuttingDown atomic.Bool
+}
+
+// NewProbe creates a Probe over the given checkers
+func NewProbe(checkers ...Checker) *Probe {
+	return &Probe{checkers: checkers}
+}
+
+// MarkShuttingDown makes every subsequent readiness check fail
+func (p *Probe) MarkShuttingDown() {
+	p.shuttingDown.Store(true)
+}
+
+// Ready returns nil when all checkers pass and shutdown has not begun
+func (p *Probe) Ready(ctx context.Context) error {
+	if p.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+	for _, c := range p.checkers {
+		if err := c.Check(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
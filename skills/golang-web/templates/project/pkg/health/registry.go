@@ -0,0 +1,148 @@
+// pkg/health/registry.go
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultCheckTimeout bounds a single dependency check
+const defaultCheckTimeout = 2 * time.Second
+
+// Status is the state of a dependency or of the service as a whole
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded" // an optional dependency is down
+	StatusDown     Status = "down"     // a critical dependency is down
+)
+
+// CheckFunc adapts a function to Checker
+type CheckFunc func(ctx context.Context) error
+
+func (f CheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckOption configures a check added with Register
+type CheckOption func(*check)
+
+// Optional marks a dependency the service can run without. Its failure
+// degrades the report instead of taking the service down.
+func Optional() CheckOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// WithTimeout overrides how long the check may take before it counts as
+// failed
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	critical bool
+	timeout  time.Duration
+}
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every registered check
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Registry holds named dependency checks and aggregates their results
+type Registry struct {
+	mu     sync.RWMutex
+	checks []*check
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check under name. Checks are critical unless Optional is
+// given. Registering a name again replaces the earlier check.
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) {
+	c := &check{name: name, checker: checker, critical: true, timeout: defaultCheckTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Check runs every check concurrently and reports each result along with
+// the overall status: down if a critical check failed, degraded if only
+// optional ones did, and up otherwise
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		switch {
+		case res.Status == StatusUp:
+		case res.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	res := CheckResult{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+	}
+	return res
+}
@@ -0,0 +1,253 @@
+// pkg/llm/client.go
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/pkg/retry"
+)
+
+// Roles used in chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Choice is one generated completion
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// Usage reports token consumption for a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Response is a chat completion response
+type Response struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// APIError is returned when the gateway responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("llm api error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// chatRequest is the body of a chat completion request
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+// CallOption customizes a single request
+type CallOption func(*chatRequest)
+
+// WithModel overrides the configured default model
+func WithModel(model string) CallOption {
+	return func(r *chatRequest) {
+		r.Model = model
+	}
+}
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(t float64) CallOption {
+	return func(r *chatRequest) {
+		r.Temperature = &t
+	}
+}
+
+// WithMaxTokens caps the number of generated tokens
+func WithMaxTokens(n int) CallOption {
+	return func(r *chatRequest) {
+		r.MaxTokens = n
+	}
+}
+
+// Client talks to an OpenAI-compatible API such as the LiteLLM proxy
+type Client struct {
+	baseURL      string
+	apiKey       string
+	defaultModel string
+	httpClient   *http.Client
+	retryPolicy  retry.Policy
+}
+
+// Option is a functional option for Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New creates a Client from the LLM config. Requests are bounded by their
+// context rather than a client-wide timeout so streams are not cut off.
+func New(cfg configs.LLMConfig, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:       cfg.APIKey,
+		defaultModel: cfg.DefaultModel,
+		httpClient:   &http.Client{},
+		retryPolicy:  retry.FromConfig(cfg.Retry, retryable),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Check implements health.Checker by listing models, which costs no
+// tokens. Any answer short of a server error means the gateway is up.
+func (c *Client) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create llm request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("llm gateway unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return parseAPIError(resp)
+	}
+	return nil
+}
+
+// Complete sends prompt as a single user message and returns the reply text
+func (c *Client) Complete(ctx context.Context, prompt string, opts ...CallOption) (string, error) {
+	resp, err := c.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("llm response has no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Chat sends a chat completion request
+func (c *Client) Chat(ctx context.Context, messages []Message, opts ...CallOption) (*Response, error) {
+	httpResp, err := c.do(ctx, c.newRequest(messages, opts))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	return &resp, nil
+}
+
+func (c *Client) newRequest(messages []Message, opts []CallOption) *chatRequest {
+	req := &chatRequest{Model: c.defaultModel, Messages: messages}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
+}
+
+// do posts req to the chat completions endpoint, retrying transient
+// failures, and returns the response if its status is 2xx. The caller must
+// close the body.
+func (c *Client) do(ctx context.Context, req *chatRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode llm request: %w", err)
+	}
+
+	var httpResp *http.Response
+	err = retry.Do(ctx, c.retryPolicy, func() error {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create llm request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if c.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("llm request failed: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			return parseAPIError(resp)
+		}
+
+		httpResp = resp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return httpResp, nil
+}
+
+// retryable retries network failures, rate limiting and gateway errors
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+func parseAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		apiErr.Message = body.Error.Message
+		apiErr.Type = body.Error.Type
+	}
+	return apiErr
+}
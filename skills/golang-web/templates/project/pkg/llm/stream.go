@@ -0,0 +1,134 @@
+// pkg/llm/stream.go
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// Chunk is an incremental piece of a streamed completion. A chunk with a
+// non-nil Err is the last one sent.
+type Chunk struct {
+	Content      string `json:"content"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Err          error  `json:"-"`
+}
+
+// streamEvent is a single SSE payload from the streaming endpoint
+type streamEvent struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// ChatStream sends a streaming chat completion request and emits chunks
+// until the gateway sends [DONE]. The channel is closed when the stream
+// ends; canceling ctx aborts the HTTP request and closes the channel. A
+// stream closed before [DONE] ends with a chunk whose Err wraps
+// io.ErrUnexpectedEOF.
+func (c *Client) ChatStream(ctx context.Context, messages []Message, opts ...CallOption) (<-chan Chunk, error) {
+	req := c.newRequest(messages, opts)
+	req.Stream = true
+
+	ctx, cancel := context.WithCancel(ctx)
+	httpResp, err := c.do(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer cancel()
+		defer close(chunks)
+		defer httpResp.Body.Close()
+
+		send := func(chunk Chunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return
+			}
+
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				send(Chunk{Err: fmt.Errorf("failed to decode llm stream: %w", err)})
+				return
+			}
+			for _, choice := range ev.Choices {
+				chunk := Chunk{Content: choice.Delta.Content}
+				if choice.FinishReason != nil {
+					chunk.FinishReason = *choice.FinishReason
+				}
+				if !send(chunk) {
+					return
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		err := scanner.Err()
+		if err == nil {
+			// Without [DONE] the completion may have been cut short
+			err = io.ErrUnexpectedEOF
+		}
+		send(Chunk{Err: fmt.Errorf("llm stream failed: %w", err)})
+	}()
+
+	return chunks, nil
+}
+
+// Relay forwards chunks to the client as server-sent events: "message"
+// for each chunk, then "done", or "error" if the stream fails. It returns
+// early if the client disconnects; cancel the context given to ChatStream
+// to stop the upstream request too.
+func Relay(c *gin.Context, chunks <-chan Chunk) {
+	sse, err := response.SSE(c)
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-sse.Done():
+			return
+		case chunk, ok := <-chunks:
+			switch {
+			case !ok:
+				_ = sse.Send(response.Event{Event: "done", Data: "[DONE]"})
+				return
+			case chunk.Err != nil:
+				_ = sse.Send(response.Event{Event: "error", Data: chunk.Err.Error()})
+				return
+			}
+			if err := sse.Send(response.Event{Event: "message", Data: chunk}); err != nil {
+				return
+			}
+		}
+	}
+}
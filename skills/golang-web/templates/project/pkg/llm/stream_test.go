@@ -0,0 +1,54 @@
+// pkg/llm/stream_test.go
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourname/myapp/configs"
+)
+
+func TestChatStream(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{"ends at done", "data: [DONE]\n\n", nil},
+		{"cut short", "", io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"hi"}}]}`+"\n\n"+tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			chunks, err := New(configs.LLMConfig{BaseURL: srv.URL}).ChatStream(context.Background(), []Message{{Role: "user", Content: "hello"}})
+			if err != nil {
+				t.Fatalf("ChatStream: %v", err)
+			}
+
+			var content string
+			var streamErr error
+			for chunk := range chunks {
+				content += chunk.Content
+				if chunk.Err != nil {
+					streamErr = chunk.Err
+				}
+			}
+			if content != "hi" {
+				t.Errorf("content = %q, want hi", content)
+			}
+			if !errors.Is(streamErr, tt.err) || (tt.err == nil) != (streamErr == nil) {
+				t.Errorf("stream error = %v, want %v", streamErr, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// pkg/lockout/lockout.go
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Store counts failed attempts and holds locks per key. Memory counts per
+// process; Redis shares counts between replicas.
+type Store interface {
+	// Fail records a failure for key and returns the number recorded in
+	// the current window, which opens with the first failure and lasts
+	// window
+	Fail(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock locks key for d
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor reports how much longer key is locked, zero if it is not
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset forgets key's failures and lock
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy decides when keys are locked
+type Policy struct {
+	MaxFailures int           // failures within Window that lock a key
+	Window      time.Duration // how long failures are counted for
+	Duration    time.Duration // how long a lock lasts
+}
+
+// Guard locks keys, such as an email and a client IP, once they see too
+// many failed attempts. If the store fails, e.g. Redis is unreachable,
+// attempts are let through and a warning is logged when failures start
+// and when they stop.
+type Guard struct {
+	store   Store
+	policy  Policy
+	logger  *slog.Logger
+	failing atomic.Bool
+}
+
+// New creates a Guard applying policy with counts kept in store
+func New(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, logger: slog.Default()}
+}
+
+// Check returns how long the longest lock among keys has left, zero if
+// none of them is locked
+func (g *Guard) Check(ctx context.Context, keys ...string) time.Duration {
+	var wait time.Duration
+	for _, key := range keys {
+		d, err := g.store.LockedFor(ctx, key)
+		if !g.ok(ctx, err) {
+			return 0
+		}
+		wait = max(wait, d)
+	}
+	return wait
+}
+
+// Fail records a failed attempt against each key, locking those that
+// reach the policy's limit. It returns how long the lock lasts if any
+// key got locked, zero otherwise.
+func (g *Guard) Fail(ctx context.Context, keys ...string) time.Duration {
+	var locked bool
+	for _, key := range keys {
+		n, err := g.store.Fail(ctx, key, g.policy.Window)
+		if !g.ok(ctx, err) {
+			return 0
+		}
+		if n < g.policy.MaxFailures {
+			continue
+		}
+		if err := g.store.Lock(ctx, key, g.policy.Duration); !g.ok(ctx, err) {
+			return 0
+		}
+		locked = true
+	}
+	if !locked {
+		return 0
+	}
+	return g.policy.Duration
+}
+
+// Succeed clears the failures recorded against keys
+func (g *Guard) Succeed(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := g.store.Reset(ctx, key); !g.ok(ctx, err) {
+			return
+		}
+	}
+}
+
+// ok reports whether err is nil, logging the store failing and recovering
+func (g *Guard) ok(ctx context.Context, err error) bool {
+	if err != nil {
+		if !g.failing.Swap(true) {
+			g.logger.WarnContext(ctx, "lockout store unavailable, allowing attempts", "error", err)
+		}
+		return false
+	}
+	if g.failing.Swap(false) {
+		g.logger.InfoContext(ctx, "lockout store recovered")
+	}
+	return true
+}
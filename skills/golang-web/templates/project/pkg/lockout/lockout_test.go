@@ -0,0 +1,82 @@
+// pkg/lockout/lockout_test.go
+package lockout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGuardLocksAfterMaxFailures(t *testing.T) {
+	ctx := context.Background()
+	g := New(NewMemory(), Policy{MaxFailures: 3, Window: time.Minute, Duration: time.Minute})
+
+	for i := 1; i < 3; i++ {
+		if wait := g.Fail(ctx, "email:a", "ip:1"); wait != 0 {
+			t.Fatalf("failure %d locked for %v", i, wait)
+		}
+	}
+	if wait := g.Fail(ctx, "email:a", "ip:1"); wait != time.Minute {
+		t.Fatalf("third failure locked for %v, want 1m", wait)
+	}
+	if wait := g.Check(ctx, "email:a", "ip:2"); wait <= 0 {
+		t.Error("locked email not reported from another client")
+	}
+	if wait := g.Check(ctx, "email:b", "ip:2"); wait != 0 {
+		t.Errorf("unrelated keys locked for %v", wait)
+	}
+}
+
+func TestGuardSucceedResetsFailures(t *testing.T) {
+	ctx := context.Background()
+	g := New(NewMemory(), Policy{MaxFailures: 2, Window: time.Minute, Duration: time.Minute})
+
+	g.Fail(ctx, "email:a")
+	g.Succeed(ctx, "email:a")
+	if wait := g.Fail(ctx, "email:a"); wait != 0 {
+		t.Errorf("failure after a success locked for %v", wait)
+	}
+}
+
+func TestGuardWindowExpires(t *testing.T) {
+	ctx := context.Background()
+	g := New(NewMemory(), Policy{MaxFailures: 2, Window: 20 * time.Millisecond, Duration: 20 * time.Millisecond})
+
+	g.Fail(ctx, "email:a")
+	time.Sleep(30 * time.Millisecond)
+	if wait := g.Fail(ctx, "email:a"); wait != 0 {
+		t.Fatalf("failure in a new window locked for %v", wait)
+	}
+	if wait := g.Fail(ctx, "email:a"); wait == 0 {
+		t.Fatal("second failure in the window did not lock")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if wait := g.Check(ctx, "email:a"); wait != 0 {
+		t.Errorf("lock outlived its duration by %v", wait)
+	}
+}
+
+// failingStore is a Store whose backend is down
+type failingStore struct{}
+
+func (failingStore) Fail(context.Context, string, time.Duration) (int, error) {
+	return 0, errors.New("down")
+}
+func (failingStore) Lock(context.Context, string, time.Duration) error { return errors.New("down") }
+func (failingStore) LockedFor(context.Context, string) (time.Duration, error) {
+	return 0, errors.New("down")
+}
+func (failingStore) Reset(context.Context, string) error { return errors.New("down") }
+
+func TestGuardFailsOpen(t *testing.T) {
+	ctx := context.Background()
+	g := New(failingStore{}, Policy{MaxFailures: 1, Window: time.Minute, Duration: time.Minute})
+
+	if wait := g.Fail(ctx, "email:a"); wait != 0 {
+		t.Errorf("Fail locked for %v with the store down", wait)
+	}
+	if wait := g.Check(ctx, "email:a"); wait != 0 {
+		t.Errorf("Check locked for %v with the store down", wait)
+	}
+}
@@ -0,0 +1,94 @@
+// pkg/lockout/memory.go
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	failures    int
+	windowEnds  time.Time
+	lockedUntil time.Time
+}
+
+// expired reports whether the entry no longer affects anything at now
+func (e *memoryEntry) expired(now time.Time) bool {
+	return now.After(e.windowEnds) && now.After(e.lockedUntil)
+}
+
+// Memory is a Store keeping counts in process. Each replica counts on its
+// own, so with N replicas a client may get up to N times the attempts.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemory creates an empty Memory store
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]*memoryEntry)}
+}
+
+func (m *Memory) Fail(_ context.Context, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	e := m.get(key, now)
+	if now.After(e.windowEnds) {
+		e.failures, e.windowEnds = 0, now.Add(window)
+	}
+	e.failures++
+	return e.failures, nil
+}
+
+func (m *Memory) Lock(_ context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.get(key, now).lockedUntil = now.Add(d)
+	return nil
+}
+
+func (m *Memory) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(0, time.Until(e.lockedUntil)), nil
+}
+
+func (m *Memory) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// get returns the entry for key, creating it if needed. Expired entries
+// are swept periodically so the store does not grow without bound. The
+// caller must hold m.mu.
+func (m *Memory) get(key string, now time.Time) *memoryEntry {
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, e := range m.entries {
+			if e.expired(now) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+	return e
+}
@@ -0,0 +1,70 @@
+// pkg/lockout/redis.go
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// failScript counts a failure, starting the window's expiry with the first
+var failScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Redis is a Store shared between every replica using the same Redis.
+// Counts and locks expire on their own.
+type Redis struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedis creates a Redis store namespacing its keys with prefix
+func NewRedis(client redis.Cmdable, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+func (r *Redis) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	n, err := failScript.Run(ctx, r.client, []string{r.failuresKey(key)}, window.Milliseconds()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("lockout fail %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func (r *Redis) Lock(ctx context.Context, key string, d time.Duration) error {
+	if err := r.client.Set(ctx, r.lockKey(key), 1, d).Err(); err != nil {
+		return fmt.Errorf("lockout lock %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *Redis) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, r.lockKey(key)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("lockout check %s: %w", key, err)
+	}
+	// Negative for a missing key or one without an expiry
+	return max(0, ttl), nil
+}
+
+func (r *Redis) Reset(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.failuresKey(key), r.lockKey(key)).Err(); err != nil {
+		return fmt.Errorf("lockout reset %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *Redis) failuresKey(key string) string {
+	return r.prefix + "failures:" + key
+}
+
+func (r *Redis) lockKey(key string) string {
+	return r.prefix + "locked:" + key
+}
@@ -0,0 +1,73 @@
+// pkg/logger/logger.go
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/yourname/myapp/configs"
+)
+
+// New builds a logger from cfg, writing to stdout. The minimum level is
+// read from level, which New initializes from cfg.Level so callers can keep
+// adjusting it at runtime. Unknown levels and formats fall back to info and
+// json with a warning.
+func New(cfg configs.LogConfig, level *slog.LevelVar) *slog.Logger {
+	lvl, levelOK := ParseLevel(cfg.Level)
+	level.Set(lvl)
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	formatOK := true
+	switch strings.ToLower(cfg.Format) {
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	default:
+		formatOK = false
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	if !levelOK {
+		logger.Warn("unknown log level, using info", "level", cfg.Level)
+	}
+	if !formatOK {
+		logger.Warn("unknown log format, using json", "format", cfg.Format)
+	}
+	return logger
+}
+
+// ParseLevel maps debug/info/warn/error to a slog.Level, reporting false
+// and returning info for anything else
+func ParseLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
+
+// LevelName is the inverse of ParseLevel, naming l the way config files do
+func LevelName(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "debug"
+	case l < slog.LevelWarn:
+		return "info"
+	case l < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
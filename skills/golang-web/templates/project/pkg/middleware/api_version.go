@@ -0,0 +1,91 @@
+// pkg/middleware/api_version.go
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// APIVersionKey is the gin context key holding the requested API version
+const APIVersionKey = "api_version"
+
+// DefaultAPIVersion applies when the Accept header names no version
+const DefaultAPIVersion = 1
+
+// APIVersionHeader echoes the version a response was shaped for
+const APIVersionHeader = "API-Version"
+
+// versionedMediaType matches e.g. application/vnd.myapp.v2+json
+var versionedMediaType = regexp.MustCompile(`^application/vnd\.myapp\.v(\d+)(\+json)?$`)
+
+// APIVersion reads the API version from a versioned Accept media type such
+// as "application/vnd.myapp.v2+json" so handlers can branch on
+// APIVersionFrom. Plain types like application/json get DefaultAPIVersion.
+// Versions missing from supported are rejected with 406; with no supported
+// list every version is accepted. This refines, not replaces, the version
+// in the URL path.
+func APIVersion(supported ...int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := requestedAPIVersion(c.GetHeader("Accept"), supported)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(APIVersionKey, version)
+		c.Header(APIVersionHeader, strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+// APIVersionFrom returns the version stored by APIVersion, or
+// DefaultAPIVersion if the middleware did not run
+func APIVersionFrom(c *gin.Context) int {
+	if v, ok := c.Get(APIVersionKey); ok {
+		if version, ok := v.(int); ok {
+			return version
+		}
+	}
+	return DefaultAPIVersion
+}
+
+// requestedAPIVersion returns the version of the first versioned media type
+// in accept
+func requestedAPIVersion(accept string, supported []int) (int, error) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		m := versionedMediaType.FindStringSubmatch(strings.ToLower(strings.TrimSpace(mediaType)))
+		if m == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(m[1])
+		if err != nil || !versionSupported(version, supported) {
+			return 0, apperrors.NotAcceptable(fmt.Sprintf("unsupported API version: v%s", m[1]))
+		}
+		return version, nil
+	}
+	return DefaultAPIVersion, nil
+}
+
+func versionSupported(version int, supported []int) bool {
+	if version < 1 {
+		return false
+	}
+	if len(supported) == 0 {
+		return true
+	}
+	for _, v := range supported {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+// pkg/middleware/body_limit.go
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unlimitedBodyKey holds the request body before any limit was applied
+const unlimitedBodyKey = "unlimited_body"
+
+// BodyLimit caps the request body at maxBytes. Reading past the limit
+// fails with *http.MaxBytesError, which errors.FromBinding reports as 413.
+// When applied more than once, e.g. to a group and to a route in it, the
+// innermost limit wins.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := c.Request.Body
+		if v, ok := c.Get(unlimitedBodyKey); ok {
+			body = v.(io.ReadCloser)
+		} else {
+			c.Set(unlimitedBodyKey, body)
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, maxBytes)
+
+		c.Next()
+	}
+}
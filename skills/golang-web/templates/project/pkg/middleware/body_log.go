@@ -0,0 +1,191 @@
+// pkg/middleware/body_log.go
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBodyLogMaxSize is how many bytes of each body are logged
+const defaultBodyLogMaxSize = 4096
+
+// redacted replaces the value of sensitive fields
+const redacted = "[REDACTED]"
+
+// defaultRedactFields are always masked; matching ignores case
+var defaultRedactFields = []string{
+	"password", "new_password", "old_password", "token", "access_token",
+	"refresh_token", "secret", "client_secret", "api_key", "authorization",
+}
+
+// BodyLogOption configures DebugBodyLog
+type BodyLogOption func(*bodyLogger)
+
+// WithBodyLogMaxSize sets how many bytes of each body are logged; the
+// rest is dropped from the log, not from the request or response
+func WithBodyLogMaxSize(n int) BodyLogOption {
+	return func(l *bodyLogger) {
+		l.maxSize = n
+	}
+}
+
+// WithBodyLogRedact masks fields in addition to the defaults, such as
+// password and api_key
+func WithBodyLogRedact(fields ...string) BodyLogOption {
+	return func(l *bodyLogger) {
+		l.redact = append(l.redact, fields...)
+	}
+}
+
+type bodyLogger struct {
+	logger  *slog.Logger
+	maxSize int
+	redact  []string
+
+	jsonField *regexp.Regexp
+	fields    map[string]struct{}
+}
+
+// DebugBodyLog logs the start of each request and response body at debug
+// level, masking sensitive fields in JSON and form bodies. Bodies are
+// copied as they stream past, so handlers and clients see them unchanged
+// and streamed responses are not held back. Only use it while debugging:
+// even redacted, bodies may hold personal data.
+func DebugBodyLog(logger *slog.Logger, opts ...BodyLogOption) gin.HandlerFunc {
+	l := &bodyLogger{
+		logger:  logger,
+		maxSize: defaultBodyLogMaxSize,
+		redact:  append([]string(nil), defaultRedactFields...),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	l.fields = make(map[string]struct{}, len(l.redact))
+	quoted := make([]string, 0, len(l.redact))
+	for _, f := range l.redact {
+		l.fields[strings.ToLower(f)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(f))
+	}
+	// Matches "field": followed by a string, possibly cut off, or a scalar
+	l.jsonField = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if !l.logger.Enabled(ctx, slog.LevelDebug) {
+			c.Next()
+			return
+		}
+
+		var reqBody []byte
+		var reqTruncated bool
+		if c.Request.Body != nil {
+			// Read one byte past the limit to learn whether there is more,
+			// then hand the handler everything, read or not
+			head, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(l.maxSize)+1))
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+			reqBody, reqTruncated = truncate(head, l.maxSize)
+		}
+
+		w := &bodyCaptureWriter{ResponseWriter: c.Writer, limit: l.maxSize}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "http body",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", w.Status()),
+			slog.String("request_id", c.GetString(RequestIDKey)),
+			slog.String("request_body", l.format(reqBody, c.ContentType())),
+			slog.Bool("request_truncated", reqTruncated),
+			slog.String("response_body", l.format(w.body.Bytes(), w.Header().Get("Content-Type"))),
+			slog.Bool("response_truncated", w.truncated),
+		)
+	}
+}
+
+// format renders a body for the log, masking sensitive fields
+func (l *bodyLogger) format(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return l.jsonField.ReplaceAllString(string(body), `${1}"`+redacted+`"`)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[unparseable form]"
+		}
+		for key := range values {
+			if _, ok := l.fields[strings.ToLower(key)]; ok {
+				values[key] = []string{redacted}
+			}
+		}
+		return values.Encode()
+	case strings.HasPrefix(mediaType, "text/") && mediaType != "text/event-stream",
+		strings.HasSuffix(mediaType, "+xml"), mediaType == "application/xml":
+		return string(body)
+	default:
+		// Binary or unknown content may hold anything; say what it was
+		return "[" + mediaType + " body omitted]"
+	}
+}
+
+// truncate cuts b to limit bytes, reporting whether anything was cut
+func truncate(b []byte, limit int) ([]byte, bool) {
+	if len(b) > limit {
+		return b[:limit], true
+	}
+	return b, false
+}
+
+// readCloser reads from a replacement reader but closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter copies the first limit bytes of the response while
+// writing everything through immediately
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	limit     int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *bodyCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	room := w.limit - w.body.Len()
+	if len(b) > room {
+		b, w.truncated = b[:max(room, 0)], true
+	}
+	w.body.Write(b)
+}
@@ -0,0 +1,240 @@
+// pkg/middleware/compress.go
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMinCompressSize is the smallest body worth compressing
+const defaultMinCompressSize = 1024
+
+// incompressibleTypes are content type prefixes that are already
+// compressed or must stream unbuffered
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/zstd", "application/x-7z-compressed", "application/x-rar",
+	"font/woff",
+	"text/event-stream",
+}
+
+// CompressOption configures Compress
+type CompressOption func(*compressor)
+
+// WithCompressionLevel sets the gzip/deflate level, from
+// gzip.BestSpeed (1) to gzip.BestCompression (9)
+func WithCompressionLevel(level int) CompressOption {
+	return func(c *compressor) {
+		c.level = level
+	}
+}
+
+// WithMinCompressSize sets the body size below which responses are sent
+// uncompressed
+func WithMinCompressSize(n int) CompressOption {
+	return func(c *compressor) {
+		c.minSize = n
+	}
+}
+
+type compressor struct {
+	level   int
+	minSize int
+}
+
+// Compress gzip- or deflate-encodes responses for clients that accept it.
+// Bodies are buffered until minSize bytes to decide; smaller bodies,
+// already encoded responses and compressed content types pass through.
+func Compress(opts ...CompressOption) gin.HandlerFunc {
+	cfg := &compressor{level: gzip.DefaultCompression, minSize: defaultMinCompressSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *gin.Context) {
+		// Caches must key on Accept-Encoding whether or not this response
+		// ends up compressed
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := acceptedEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, cfg: cfg, encoding: encoding}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptedEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip, or returns "" if neither is acceptable. A coding named
+// in the header, even with q=0, is not covered by *.
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		accepted[strings.ToLower(name)] = q > 0
+	}
+
+	acceptable := func(coding string) bool {
+		if ok, named := accepted[coding]; named {
+			return ok
+		}
+		return accepted["*"]
+	}
+
+	switch {
+	case acceptable("gzip"):
+		return "gzip"
+	case acceptable("deflate"):
+		return "deflate"
+	default:
+		return ""
+	}
+}
+
+// compressWriter buffers the start of the body until it knows whether to
+// compress, then streams through the encoder or straight to the client
+type compressWriter struct {
+	gin.ResponseWriter
+	cfg      *compressor
+	encoding string
+
+	buf     []byte
+	wrote   bool
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.cfg.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports buffered writes too, so later middleware does not try to
+// write a second response
+func (w *compressWriter) Written() bool {
+	return w.wrote || w.ResponseWriter.Written()
+}
+
+// Unwrap gives http.ResponseController access to the connection, e.g. so
+// streams can lift the write deadline
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush sends what is buffered so far, e.g. for streamed responses
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide starts compressing if the response qualifies, then writes out the
+// buffered bytes
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	if w.shouldCompress() {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+
+		var err error
+		if w.encoding == "gzip" {
+			w.enc, err = gzip.NewWriterLevel(w.ResponseWriter, w.cfg.level)
+		} else {
+			w.enc, err = flate.NewWriter(w.ResponseWriter, w.cfg.level)
+		}
+		if err != nil {
+			w.enc = nil
+			return err
+		}
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) shouldCompress() bool {
+	if len(w.buf) < w.cfg.minSize {
+		return false
+	}
+	switch w.Status() {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish writes out a body that stayed under minSize and closes the encoder
+func (w *compressWriter) finish() {
+	if !w.decided && w.wrote {
+		_ = w.decide()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
@@ -0,0 +1,24 @@
+// pkg/middleware/compress_test.go
+package middleware
+
+import "testing"
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"gzip":                       "gzip",
+		"deflate, gzip":              "gzip",
+		"deflate":                    "deflate",
+		"gzip;q=0, deflate":          "deflate",
+		"*":                          "gzip",
+		"gzip;q=0, *":                "deflate",
+		"gzip ; q=0, deflate;q=0, *": "",
+		"br, identity":               "",
+		"*;q=0":                      "",
+	}
+	for header, want := range tests {
+		if got := acceptedEncoding(header); got != want {
+			t.Errorf("acceptedEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
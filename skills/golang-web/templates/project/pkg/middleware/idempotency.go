@@ -0,0 +1,208 @@
+// pkg/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/auth"
+	"github.com/yourname/myapp/pkg/ctxkeys"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// IdempotencyKeyHeader carries the client-chosen idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ErrRequestInProgress is returned while another request with the same
+// idempotency key is still being processed
+var ErrRequestInProgress = errors.Sentinel(errors.KindConflict, "request in progress")
+
+// ErrIdempotencyKeyReused is returned when a key is sent again with a
+// different body
+var ErrIdempotencyKeyReused = errors.Sentinel(errors.KindUnprocessable, "idempotency key was used with a different request body")
+
+// CachedResponse is a response recorded for replay
+type CachedResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+	// RequestHash is the SHA-256 of the request body that produced it
+	RequestHash string
+}
+
+// IdempotencyStore records responses by idempotency key
+type IdempotencyStore interface {
+	// Reserve claims key for a new request. It returns the cached response
+	// if key already completed, or ErrRequestInProgress if it is held.
+	Reserve(ctx context.Context, key string) (*CachedResponse, error)
+	// Save stores the response for key and ends the reservation
+	Save(ctx context.Context, key string, resp *CachedResponse) error
+	// Release drops the reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency replays the first response for a repeated Idempotency-Key.
+// Keys are scoped to the caller, or the client IP before Auth, and to the
+// tenant, so nobody can replay another's response. Reusing a key with a
+// different body gets a 422. Server errors, unwritten responses and panics
+// are not cached so clients can retry them. Requests without the header
+// pass through unchanged.
+func Idempotency(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		key = c.Request.Method + " " + c.FullPath() + " " + idempotencyScope(c) + " " + key
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.Error(c, errors.FromBinding(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		cached, err := store.Reserve(ctx, key)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+		if cached != nil {
+			if cached.RequestHash != hash {
+				response.Error(c, ErrIdempotencyKeyReused)
+			} else {
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(cached.Status, cached.ContentType, cached.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		// Released unless stored, including when the handler panics
+		stored := false
+		defer func() {
+			if !stored {
+				_ = store.Release(context.WithoutCancel(ctx), key)
+			}
+		}()
+
+		w := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		status := w.Status()
+		if !w.Written() || status >= 500 {
+			return
+		}
+		stored = store.Save(ctx, key, &CachedResponse{
+			Status:      status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+			RequestHash: hash,
+		}) == nil
+	}
+}
+
+// idempotencyScope identifies whose keys a request's key is among: the
+// authenticated user if any, else the client IP, within the tenant
+func idempotencyScope(c *gin.Context) string {
+	ctx := c.Request.Context()
+	principal := "ip:" + c.ClientIP()
+	if user, ok := auth.UserFromContext(ctx); ok {
+		principal = "user:" + user.ID
+	}
+	return ctxkeys.TenantID(ctx) + "/" + principal
+}
+
+// bodyRecorder tees the response body into a buffer
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+type idempotencyEntry struct {
+	resp      *CachedResponse // nil while in progress
+	expiresAt time.Time
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore whose entries
+// expire after a TTL. Use a shared store when running multiple replicas.
+type MemoryIdempotencyStore struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > s.ttl {
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		if e.resp == nil {
+			return nil, ErrRequestInProgress
+		}
+		return e.resp, nil
+	}
+
+	s.entries[key] = &idempotencyEntry{expiresAt: now.Add(s.ttl)}
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, resp *CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{resp: resp, expiresAt: time.Now().Add(s.ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
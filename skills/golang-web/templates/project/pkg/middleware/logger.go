@@ -0,0 +1,161 @@
+// pkg/middleware/logger.go
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggerOption configures SlogLogger
+type LoggerOption func(*requestLogger)
+
+// WithSkipPaths leaves requests to paths out of the log (typically health
+// probes)
+func WithSkipPaths(paths ...string) LoggerOption {
+	return func(l *requestLogger) {
+		for _, p := range paths {
+			l.skip[p] = struct{}{}
+		}
+	}
+}
+
+// WithSampleRate logs only 1 in n successful requests at info; the others
+// are logged at debug. 1 or less logs all of them at info.
+func WithSampleRate(n int) LoggerOption {
+	return func(l *requestLogger) {
+		l.global = newSampler(n)
+	}
+}
+
+// WithRouteSampleRate overrides the sample rate for a route, given as the
+// pattern it was registered with, e.g. "/api/v1/users/:id". An empty method
+// matches any; a rate for the method wins over one for any method.
+func WithRouteSampleRate(method, route string, n int) LoggerOption {
+	return func(l *requestLogger) {
+		l.routes[routeKey(method, route)] = newSampler(n)
+	}
+}
+
+// WithSlowThreshold logs requests taking d or longer at warn, whatever
+// the sample rate. Zero disables it.
+func WithSlowThreshold(d time.Duration) LoggerOption {
+	return func(l *requestLogger) {
+		l.slow = d
+	}
+}
+
+type requestLogger struct {
+	logger *slog.Logger
+	skip   map[string]struct{}
+	global *sampler
+	routes map[string]*sampler
+	slow   time.Duration
+}
+
+// sampler picks 1 in rate calls, starting with the first
+type sampler struct {
+	rate uint64
+	n    atomic.Uint64
+}
+
+func newSampler(rate int) *sampler {
+	return &sampler{rate: uint64(max(rate, 1))}
+}
+
+func (s *sampler) sample() bool {
+	return s.rate == 1 || s.n.Add(1)%s.rate == 1
+}
+
+// routeKey is "METHOD route", or just route for any method
+func routeKey(method, route string) string {
+	if method == "" {
+		return route
+	}
+	return strings.ToUpper(method) + " " + route
+}
+
+// SlogLogger logs each request as structured attributes. Server errors
+// are logged at error and client errors and slow requests at warn, always;
+// other requests are logged at info as sampled and at debug otherwise, so
+// the debug level shows every request.
+func SlogLogger(logger *slog.Logger, opts ...LoggerOption) gin.HandlerFunc {
+	l := &requestLogger{
+		logger: logger,
+		skip:   make(map[string]struct{}),
+		global: newSampler(1),
+		routes: make(map[string]*sampler),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if _, ok := l.skip[path]; ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		latency := time.Since(start)
+
+		var level slog.Level
+		var extra []slog.Attr
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case l.slow > 0 && latency >= l.slow:
+			level = slog.LevelWarn
+			extra = append(extra, slog.Bool("slow", true))
+		default:
+			s := l.sampler(c)
+			if !s.sample() {
+				level = slog.LevelDebug
+				break
+			}
+			level = slog.LevelInfo
+			if s.rate > 1 {
+				// Lets log queries scale counts back up
+				extra = append(extra, slog.Uint64("sample_rate", s.rate))
+			}
+		}
+
+		ctx := c.Request.Context()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+		logger.LogAttrs(ctx, level, "http request", append([]slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.Int("status", status),
+			slog.Duration("latency", latency),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("request_id", c.GetString(RequestIDKey)),
+		}, extra...)...)
+	}
+}
+
+// sampler returns the sampler for the request's route
+func (l *requestLogger) sampler(c *gin.Context) *sampler {
+	if len(l.routes) == 0 {
+		return l.global
+	}
+	route := c.FullPath()
+	if s, ok := l.routes[routeKey(c.Request.Method, route)]; ok {
+		return s
+	}
+	if s, ok := l.routes[route]; ok {
+		return s
+	}
+	return l.global
+}
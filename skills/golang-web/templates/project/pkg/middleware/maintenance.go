@@ -0,0 +1,71 @@
+// pkg/middleware/maintenance.go
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// defaultMaintenanceRetryAfter is the Retry-After sent while in maintenance
+const defaultMaintenanceRetryAfter = 2 * time.Minute
+
+// MaintenanceOption configures Maintenance
+type MaintenanceOption func(*maintenance)
+
+// WithMaintenanceRetryAfter sets how long clients are told to wait before
+// retrying
+func WithMaintenanceRetryAfter(d time.Duration) MaintenanceOption {
+	return func(m *maintenance) {
+		m.retryAfter = d
+	}
+}
+
+// WithMaintenanceExempt lets requests for paths through while in
+// maintenance, e.g. probes and the endpoint that ends maintenance
+func WithMaintenanceExempt(paths ...string) MaintenanceOption {
+	return func(m *maintenance) {
+		for _, p := range paths {
+			m.exempt[p] = struct{}{}
+		}
+	}
+}
+
+type maintenance struct {
+	retryAfter time.Duration
+	exempt     map[string]struct{}
+}
+
+// Maintenance responds 503 with Retry-After to every request not exempted
+// while enabled is set. The flag is read per request, so storing to it
+// takes effect immediately.
+func Maintenance(enabled *atomic.Bool, opts ...MaintenanceOption) gin.HandlerFunc {
+	m := &maintenance{
+		retryAfter: defaultMaintenanceRetryAfter,
+		exempt:     make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(m.retryAfter.Seconds())))
+
+	return func(c *gin.Context) {
+		if !enabled.Load() {
+			c.Next()
+			return
+		}
+		if _, ok := m.exempt[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", retryAfter)
+		response.Error(c, errors.ErrMaintenance)
+		c.Abort()
+	}
+}
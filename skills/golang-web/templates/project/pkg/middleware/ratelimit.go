@@ -0,0 +1,197 @@
+// pkg/middleware/ratelimit.go
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long an unused client limiter is kept in memory
+const limiterIdleTTL = 3 * time.Minute
+
+// LimitResult is the outcome of taking one request from a bucket
+type LimitResult struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // requests left in the bucket
+	RetryAfter time.Duration // when Allowed is false, how long until one is
+	Reset      time.Duration // how long until the bucket is full again
+}
+
+// Limiter decides whether the client behind key may make another request.
+// MemoryLimiter limits per process; RedisLimiter shares buckets between
+// replicas.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (LimitResult, error)
+}
+
+// KeyFunc derives the rate-limit bucket for a request
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitOption configures RateLimit
+type RateLimitOption func(*rateLimiter)
+
+// WithKeyFunc overrides the default client-IP keying, e.g. to limit
+// authenticated users by user ID
+func WithKeyFunc(fn KeyFunc) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.keyFunc = fn
+	}
+}
+
+// WithRateLimitLogger sets where limiter failures are logged, instead of
+// slog.Default()
+func WithRateLimitLogger(logger *slog.Logger) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.logger = logger
+	}
+}
+
+type rateLimiter struct {
+	limiter Limiter
+	keyFunc KeyFunc
+	logger  *slog.Logger
+	failing atomic.Bool
+}
+
+// RateLimit takes one request per call from the client's bucket in
+// limiter, responding 429 with Retry-After once the bucket is empty.
+// X-RateLimit-Reset gives the seconds until the bucket is full again. If
+// the limiter fails, e.g. Redis is unreachable, requests are let through
+// and a warning is logged when failures start and when they stop.
+func RateLimit(limiter Limiter, opts ...RateLimitOption) gin.HandlerFunc {
+	l := &rateLimiter{
+		limiter: limiter,
+		keyFunc: func(c *gin.Context) string { return c.ClientIP() },
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		res, err := l.limiter.Allow(ctx, l.keyFunc(c))
+		if err != nil {
+			// Fail open: an outage of the limiter must not take the API down
+			if !l.failing.Swap(true) {
+				l.logger.WarnContext(ctx, "rate limiter unavailable, allowing requests", "error", err)
+			}
+			c.Next()
+			return
+		}
+		if l.failing.Swap(false) {
+			l.logger.InfoContext(ctx, "rate limiter recovered")
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+			response.Error(c, errors.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// MemoryLimiter is a Limiter keeping a token bucket per client in memory.
+// Each replica limits on its own.
+type MemoryLimiter struct {
+	limit     rate.Limit
+	burst     int
+	mu        sync.Mutex
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter allows r requests per second with the given burst per
+// client
+func NewMemoryLimiter(r rate.Limit, burst int) *MemoryLimiter {
+	return &MemoryLimiter{
+		limit:   r,
+		burst:   burst,
+		entries: make(map[string]*limiterEntry),
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (LimitResult, error) {
+	now := time.Now()
+	lim, burst := l.get(key, now)
+
+	res := lim.ReserveN(now, 1)
+	if delay := res.DelayFrom(now); !res.OK() || delay > 0 {
+		res.CancelAt(now)
+		return LimitResult{Limit: burst, RetryAfter: delay, Reset: refillTime(lim, burst, now)}, nil
+	}
+	return LimitResult{
+		Allowed:   true,
+		Limit:     burst,
+		Remaining: int(lim.TokensAt(now)),
+		Reset:     refillTime(lim, burst, now),
+	}, nil
+}
+
+// refillTime returns how long lim takes to refill to burst tokens
+func refillTime(lim *rate.Limiter, burst int, now time.Time) time.Duration {
+	missing := float64(burst) - lim.TokensAt(now)
+	if missing <= 0 || lim.Limit() <= 0 {
+		return 0
+	}
+	return time.Duration(missing / float64(lim.Limit()) * float64(time.Second))
+}
+
+// SetLimit changes the rate and burst for every client. Tokens already
+// used are kept, so a client cannot reset its bucket by waiting for one.
+func (l *MemoryLimiter) SetLimit(r rate.Limit, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit, l.burst = r, burst
+	now := time.Now()
+	for _, e := range l.entries {
+		e.limiter.SetLimitAt(now, r)
+		e.limiter.SetBurstAt(now, burst)
+	}
+}
+
+// get returns the limiter for key along with the current burst, sweeping
+// idle entries periodically so the store does not grow without bound
+func (l *MemoryLimiter) get(key string, now time.Time) (*rate.Limiter, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, e := range l.entries {
+			if now.Sub(e.lastSeen) > limiterIdleTTL {
+				delete(l.entries, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &limiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.entries[key] = e
+	}
+	e.lastSeen = now
+	return e.limiter, l.burst
+}
@@ -0,0 +1,130 @@
+// pkg/middleware/ratelimit_redis.go
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// tokenBucketScript refills the bucket for the time since it was last
+// touched, then takes a token if one is left. The clock is Redis's, so
+// replicas with skewed clocks share one view of each bucket. Fractions are
+// returned as strings since Redis truncates Lua numbers to integers.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = (1 - tokens) / rate
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000))
+return {allowed, tostring(tokens), tostring(retry)}
+`)
+
+// RedisLimiterOption configures a RedisLimiter
+type RedisLimiterOption func(*RedisLimiter)
+
+// WithRedisKeyPrefix namespaces bucket keys, e.g. when apps share a Redis
+func WithRedisKeyPrefix(prefix string) RedisLimiterOption {
+	return func(l *RedisLimiter) {
+		l.prefix = prefix
+	}
+}
+
+// RedisLimiter is a Limiter keeping token buckets in Redis, so every
+// replica draws from the same bucket per client. Buckets expire once they
+// would have refilled.
+type RedisLimiter struct {
+	client redis.Scripter
+	prefix string
+
+	mu    sync.RWMutex
+	limit rate.Limit
+	burst int
+}
+
+// NewRedisLimiter allows r requests per second with the given burst per
+// client across every replica sharing client. r must be positive.
+func NewRedisLimiter(client redis.Scripter, r rate.Limit, burst int, opts ...RedisLimiterOption) *RedisLimiter {
+	l := &RedisLimiter{
+		client: client,
+		limit:  r,
+		burst:  burst,
+		prefix: "ratelimit:",
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (LimitResult, error) {
+	l.mu.RLock()
+	limit, burst := l.limit, l.burst
+	l.mu.RUnlock()
+
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, float64(limit), burst).Slice()
+	if err != nil {
+		return LimitResult{}, fmt.Errorf("rate limit script: %w", err)
+	}
+	if len(res) != 3 {
+		return LimitResult{}, fmt.Errorf("rate limit script: unexpected reply %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokens, err := parseScriptFloat(res[1])
+	if err != nil {
+		return LimitResult{}, err
+	}
+	retry, err := parseScriptFloat(res[2])
+	if err != nil {
+		return LimitResult{}, err
+	}
+
+	return LimitResult{
+		Allowed:    allowed == 1,
+		Limit:      burst,
+		Remaining:  int(math.Floor(tokens)),
+		RetryAfter: time.Duration(retry * float64(time.Second)),
+		Reset:      time.Duration((float64(burst) - tokens) / float64(limit) * float64(time.Second)),
+	}, nil
+}
+
+// SetLimit changes the rate and burst for every client of this replica.
+// Buckets keep their tokens; replicas should be given the same limits.
+// r must be positive.
+func (l *RedisLimiter) SetLimit(r rate.Limit, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.burst = r, burst
+}
+
+// parseScriptFloat reads a number the script returned as a string
+func parseScriptFloat(v interface{}) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("rate limit script: unexpected value %v", v)
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,41 @@
+// pkg/middleware/ratelimit_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", RateLimit(NewMemoryLimiter(1, 2)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	get()
+	w := get()
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("second request = %d with %q remaining, want 200 with 0", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w = get()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	// Two tokens at one per second
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "2" {
+		t.Errorf("X-RateLimit-Reset = %q, want 2", got)
+	}
+}
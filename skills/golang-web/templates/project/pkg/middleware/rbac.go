@@ -0,0 +1,52 @@
+// pkg/middleware/rbac.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// RequireRole allows the request if the caller has any of roles. It must
+// run after Auth; without claims the request is rejected with 401.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return requireClaim(func(claims *Claims) bool {
+		return containsAny(claims.Roles, roles)
+	})
+}
+
+// RequirePermission allows the request if the caller has perm. It must run
+// after Auth; without claims the request is rejected with 401.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return requireClaim(func(claims *Claims) bool {
+		return containsAny(claims.Permissions, []string{perm})
+	})
+}
+
+func requireClaim(allowed func(*Claims) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		if !ok {
+			response.Error(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		if !allowed(claims) {
+			response.Error(c, errors.ErrForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func containsAny(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+// pkg/middleware/recovery.go
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/alert"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// Recovery turns a panic into a 500 with the unified JSON envelope, logs
+// it with its stack and request ID and passes it to the alert reporter.
+// The panic value is only included in the response in gin debug mode.
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let net/http handle deliberate aborts
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			attrs := []slog.Attr{
+				slog.Any("panic", rec),
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("request_id", c.GetString(RequestIDKey)),
+			}
+
+			// The client went away; there is nobody to respond to
+			if brokenPipe(rec) {
+				logger.LogAttrs(c.Request.Context(), slog.LevelWarn, "connection lost", attrs...)
+				c.Abort()
+				return
+			}
+
+			attrs = append(attrs, slog.String("stack", string(debug.Stack())))
+			logger.LogAttrs(c.Request.Context(), slog.LevelError, "panic recovered", attrs...)
+
+			perr := alert.NewPanicError(rec)
+			if c.Writer.Written() {
+				// response.Error reports the others
+				alert.Report(c.Request.Context(), alert.Event{
+					Err:       perr,
+					Request:   c.Request,
+					RequestID: c.GetString(RequestIDKey),
+				})
+				c.Abort()
+				return
+			}
+			message := apperrors.ErrInternal.Message
+			if gin.IsDebugging() {
+				message = perr.Error()
+			}
+			response.Error(c, apperrors.Wrap(perr, apperrors.KindInternal, message))
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// brokenPipe reports whether rec was caused by writing to a closed connection
+func brokenPipe(rec interface{}) bool {
+	err, ok := rec.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
@@ -0,0 +1,50 @@
+// pkg/middleware/secure_headers.go
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/configs"
+)
+
+// SecureHeaders sets the security headers configured in cfg on every
+// response. Handlers may override them, e.g. to relax the CSP for a page
+// that runs scripts. Strict-Transport-Security is only sent on requests
+// that arrived over TLS, since browsers ignore it on plain HTTP; behind a
+// TLS-terminating proxy, let the proxy send it.
+func SecureHeaders(cfg configs.SecureHeadersConfig) gin.HandlerFunc {
+	static := make(map[string]string)
+	for name, value := range map[string]string{
+		"X-Content-Type-Options":  cfg.ContentTypeOptions,
+		"X-Frame-Options":         cfg.FrameOptions,
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+	} {
+		if value != "" {
+			static[name] = value
+		}
+	}
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		for name, value := range static {
+			h.Set(name, value)
+		}
+		if hsts != "" && c.Request.TLS != nil {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
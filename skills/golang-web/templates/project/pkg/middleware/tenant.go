@@ -0,0 +1,153 @@
+// pkg/middleware/tenant.go
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/configs"
+	"github.com/yourname/myapp/pkg/ctxkeys"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+const (
+	// TenantHeader is the header naming the tenant of a request
+	TenantHeader = "X-Tenant-ID"
+
+	// TenantKey is the gin context key holding the tenant ID
+	TenantKey = "tenant_id"
+
+	// maxTenantIDLength is the size of the tenant_id columns
+	maxTenantIDLength = 64
+)
+
+// tenantSource names the tenant of a request, or returns "" if it has none
+type tenantSource struct {
+	name    string // the field reported when the ID is malformed
+	resolve func(c *gin.Context) string
+}
+
+// TenantOption adds a place Tenant looks for the tenant ID
+type TenantOption func(*[]tenantSource)
+
+// WithTenantHeader takes the tenant ID from the header name, e.g.
+// TenantHeader
+func WithTenantHeader(name string) TenantOption {
+	return func(sources *[]tenantSource) {
+		*sources = append(*sources, tenantSource{
+			name:    name,
+			resolve: func(c *gin.Context) string { return c.GetHeader(name) },
+		})
+	}
+}
+
+// WithTenantSubdomain takes the tenant ID from the first label of a host
+// under domain, so acme.example.com under example.com is tenant acme.
+// Requests to domain itself, or to other hosts, name no tenant.
+func WithTenantSubdomain(domain string) TenantOption {
+	suffix := "." + strings.ToLower(domain)
+	return func(sources *[]tenantSource) {
+		*sources = append(*sources, tenantSource{
+			name: "host",
+			resolve: func(c *gin.Context) string {
+				host, _, err := net.SplitHostPort(c.Request.Host)
+				if err != nil {
+					host = c.Request.Host
+				}
+				// Host names are case-insensitive, tenant IDs lowercase
+				label, ok := strings.CutSuffix(strings.ToLower(host), suffix)
+				if !ok {
+					return ""
+				}
+				return label
+			},
+		})
+	}
+}
+
+// WithTenantClaim takes the tenant ID from the tenant claim of the bearer
+// token, verified as Auth verifies it. Invalid tokens name no tenant; Auth
+// rejects them on the routes that require one.
+func WithTenantClaim(cfg configs.AuthConfig) TenantOption {
+	parse := claimsParser(cfg)
+	return func(sources *[]tenantSource) {
+		*sources = append(*sources, tenantSource{
+			name: "token",
+			resolve: func(c *gin.Context) string {
+				raw, ok := bearerToken(c)
+				if !ok {
+					return ""
+				}
+				claims, err := parse(raw)
+				if err != nil {
+					return ""
+				}
+				return claims.Tenant
+			},
+		})
+	}
+}
+
+// Tenant scopes each request to the tenant named by the first of the
+// sources given in opts that names one, storing it in the gin context and
+// in the request context, where the database scopes statements by it.
+// Requests naming no tenant, or a malformed one, get a 400.
+//
+// Auth rejects tokens issued in a tenant other than the request's, so a
+// client naming a tenant by header or host cannot act as a user of
+// another. Routes without Auth are as open in every tenant as they would
+// be without tenancy.
+func Tenant(opts ...TenantOption) gin.HandlerFunc {
+	var sources []tenantSource
+	for _, opt := range opts {
+		opt(&sources)
+	}
+
+	return func(c *gin.Context) {
+		var id string
+		for _, source := range sources {
+			if id = source.resolve(c); id == "" {
+				continue
+			}
+			if !validTenantID(id) {
+				response.Error(c, errors.InvalidParams(errors.FieldError{
+					Field:   source.name,
+					Tag:     "tenant",
+					Message: "tenant ID must be 1-64 lowercase letters, digits or hyphens",
+				}))
+				c.Abort()
+				return
+			}
+			break
+		}
+		if id == "" {
+			response.Error(c, errors.InvalidParams(errors.FieldError{
+				Field:   "tenant",
+				Tag:     "required",
+				Message: "the request names no tenant",
+			}))
+			c.Abort()
+			return
+		}
+
+		c.Set(TenantKey, id)
+		c.Request = c.Request.WithContext(ctxkeys.WithTenantID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validTenantID accepts IDs usable as a DNS label: lowercase letters,
+// digits and inner hyphens
+func validTenantID(id string) bool {
+	if id == "" || len(id) > maxTenantIDLength || id[0] == '-' || id[len(id)-1] == '-' {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+// pkg/middleware/timeout.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// Timeout bounds the request context with a deadline. Handlers run
+// synchronously and must honor the context (GORM calls do); if the deadline
+// passes before a response is written, the client receives a 504. A zero
+// or negative d disables the deadline.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	if d <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			response.Error(c, apperrors.ErrTimeout)
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// pkg/middleware/timeout_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		sleep   time.Duration
+		status  int
+	}{
+		{"disabled when zero", 0, 0, http.StatusOK},
+		{"within the deadline", time.Second, 0, http.StatusOK},
+		{"past the deadline", 10 * time.Millisecond, 50 * time.Millisecond, http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.GET("/", Timeout(tt.timeout), func(c *gin.Context) {
+				select {
+				case <-time.After(tt.sleep):
+					c.Status(http.StatusOK)
+				case <-c.Request.Context().Done():
+				}
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// pkg/middleware/timing.go
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/timing"
+)
+
+// TimingOption configures Timing
+type TimingOption func(*timingConfig)
+
+type timingConfig struct {
+	serverTiming bool
+}
+
+// WithServerTiming adds a Server-Timing header breaking the response time
+// down by the phases recorded with the timing package. It reveals how the
+// server spends its time, so only enable it while debugging.
+func WithServerTiming(enabled bool) TimingOption {
+	return func(cfg *timingConfig) {
+		cfg.serverTiming = enabled
+	}
+}
+
+// Timing sets X-Response-Time to the milliseconds spent before the
+// response headers were written
+func Timing(opts ...TimingOption) gin.HandlerFunc {
+	cfg := &timingConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		var rec *timing.Recorder
+		if cfg.serverTiming {
+			var ctx context.Context
+			ctx, rec = timing.WithRecorder(c.Request.Context())
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		w := &timingWriter{ResponseWriter: c.Writer, start: start, recorder: rec}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		// Responses without a body have not written their headers yet
+		if !w.Written() {
+			w.setHeaders()
+		}
+	}
+}
+
+// timingWriter sets the timing headers just before the headers are sent,
+// since they cannot be added afterwards
+type timingWriter struct {
+	gin.ResponseWriter
+	start    time.Time
+	recorder *timing.Recorder
+	done     bool
+}
+
+func (w *timingWriter) setHeaders() {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	elapsed := time.Since(w.start)
+	w.Header().Set("X-Response-Time", timing.Milliseconds(elapsed)+"ms")
+	if w.recorder != nil {
+		w.recorder.Add("handler", elapsed)
+		w.Header().Set("Server-Timing", w.recorder.ServerTiming())
+	}
+}
+
+func (w *timingWriter) WriteHeaderNow() {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush sends the headers first when a streamed response starts
+func (w *timingWriter) Flush() {
+	w.setHeaders()
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
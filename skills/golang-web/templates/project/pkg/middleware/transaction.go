@@ -0,0 +1,120 @@
+// pkg/middleware/transaction.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/database"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+// errRollback marks a transaction rolled back because of the response status
+var errRollback = errors.New("rollback: handler did not succeed")
+
+// Transactional runs the rest of the chain in a database transaction that
+// repositories join through the request context. It commits when the
+// handler responds with a 2xx status and rolls back otherwise. Opt in per
+// route group:
+//
+//	orders := v1.Group("/orders", middleware.Transactional(db))
+//
+// The response is buffered until the commit succeeds, so a failed commit
+// is reported as a 500 rather than after a success was already sent; that
+// makes it unsuitable for streaming and WebSocket routes. Service-level
+// transactions inside the request become savepoints, and the transaction
+// is never retried because the handler cannot be replayed.
+func Transactional(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		real := c.Writer
+		w := &txWriter{ResponseWriter: real, status: http.StatusOK}
+
+		err := db.WithTransactionOnce(c.Request.Context(), func(ctx context.Context) error {
+			c.Request = c.Request.WithContext(ctx)
+			c.Writer = w
+			// Restore the real writer even if the handler panics, so
+			// Recovery can still respond
+			defer func() { c.Writer = real }()
+
+			c.Next()
+
+			if w.status < 200 || w.status > 299 {
+				return errRollback
+			}
+			return nil
+		})
+
+		if err != nil && !errors.Is(err, errRollback) {
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) {
+				err = apperrors.Wrap(err, apperrors.KindInternal, "failed to commit transaction")
+			}
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+		w.flush()
+	}
+}
+
+// txWriter holds the response back until the transaction outcome is known.
+// Headers go straight to the underlying writer's header map.
+type txWriter struct {
+	gin.ResponseWriter
+	status int
+	wrote  bool
+	body   bytes.Buffer
+}
+
+func (w *txWriter) WriteHeader(code int) {
+	if code > 0 && !w.wrote {
+		w.status = code
+	}
+}
+
+func (w *txWriter) WriteHeaderNow() {
+	w.wrote = true
+}
+
+func (w *txWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.body.Write(b)
+}
+
+func (w *txWriter) WriteString(s string) (int, error) {
+	w.wrote = true
+	return w.body.WriteString(s)
+}
+
+func (w *txWriter) Status() int {
+	return w.status
+}
+
+func (w *txWriter) Size() int {
+	if !w.wrote {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *txWriter) Written() bool {
+	return w.wrote
+}
+
+// Flush is a no-op; nothing reaches the client before the commit
+func (w *txWriter) Flush() {}
+
+// flush sends the buffered response to the underlying writer. The status
+// goes through even without a body, as set by c.Status(204).
+func (w *txWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if !w.wrote {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,81 @@
+// pkg/middleware/transaction_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/database"
+)
+
+// newTransactionalEngine serves POST /items, which inserts name and
+// responds with status and, unless empty, body
+func newTransactionalEngine(t *testing.T, status int, body string) (*gin.Engine, *database.Database) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.New(database.Config{Driver: "sqlite", Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.DB().Exec("CREATE TABLE items (name TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	r := gin.New()
+	r.POST("/items", Transactional(db), func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if err := database.Conn(ctx, db.DB()).Exec("INSERT INTO items (name) VALUES (?)", "a").Error; err != nil {
+			t.Errorf("insert: %v", err)
+		}
+		if body == "" {
+			c.Status(status)
+			return
+		}
+		c.String(status, body)
+	})
+	return r, db
+}
+
+func countItems(t *testing.T, db *database.Database) int64 {
+	t.Helper()
+
+	var n int64
+	if err := db.DB().Table("items").Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestTransactional(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		rows   int64
+	}{
+		{"commits on success", http.StatusCreated, "created", 1},
+		{"commits without a body", http.StatusNoContent, "", 1},
+		{"rolls back on failure", http.StatusConflict, "conflict", 0},
+		{"rolls back without a body", http.StatusNotModified, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, db := newTransactionalEngine(t, tt.status, tt.body)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", nil))
+
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("response = %d %q, want %d %q", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			if n := countItems(t, db); n != tt.rows {
+				t.Errorf("%d rows after the request, want %d", n, tt.rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// pkg/migrate/migrate.go
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// ErrDirty is returned when a previous migration failed part-way and the
+// schema must be repaired by hand before migrating again
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migrator applies versioned SQL migrations and records the applied
+// version in the schema_migrations table
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// Status compares the applied migration version with the latest one
+// available
+type Status struct {
+	Current uint
+	Latest  uint
+	Dirty   bool
+}
+
+// Behind reports whether migrations are pending
+func (s Status) Behind() bool {
+	return s.Current < s.Latest
+}
+
+// Ahead reports whether the database has migrations this build lacks,
+// e.g. after rolling back the code but not the schema
+func (s Status) Ahead() bool {
+	return s.Current > s.Latest
+}
+
+// New creates a Migrator for db using the migrations in the fsys directory
+// named after driver (postgres, mysql or sqlite)
+func New(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	var (
+		target database.Driver
+		err    error
+	)
+	switch driver {
+	case "postgres":
+		target, err = pgx.WithInstance(db, &pgx.Config{})
+	case "mysql":
+		target, err = mysql.WithInstance(db, &mysql.Config{})
+	case "sqlite":
+		target, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s (supported: postgres, mysql, sqlite)", driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	dir, err := fs.Sub(fsys, driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations for %s: %w", driver, err)
+	}
+	src, err := iofs.New(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, driver, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	if err := m.checkClean(); err != nil {
+		return err
+	}
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down() error {
+	if err := m.checkClean(); err != nil {
+		return err
+	}
+	if err := m.m.Steps(-1); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+	return nil
+}
+
+// Version returns the applied migration version, 0 if none has been applied
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Latest returns the newest migration version available, 0 if there are
+// no migrations
+func (m *Migrator) Latest() (uint, error) {
+	version, err := m.source.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	for err == nil {
+		var next uint
+		if next, err = m.source.Next(version); err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	return version, nil
+}
+
+// Status returns the applied and latest migration versions
+func (m *Migrator) Status() (Status, error) {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	latest, err := m.Latest()
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{Current: current, Latest: latest, Dirty: dirty}, nil
+}
+
+// Close releases the migration source and database driver. The driver
+// closes the *sql.DB passed to New.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+func (m *Migrator) checkClean() error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+	return nil
+}
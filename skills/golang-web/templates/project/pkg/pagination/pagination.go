@@ -0,0 +1,152 @@
+// pkg/pagination/pagination.go
+package pagination
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+)
+
+const (
+	defaultPageSize    = 20
+	defaultMaxPageSize = 100
+)
+
+// SortField orders by Column, descending if Desc
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Params is a validated page request
+type Params struct {
+	Page     int // 1-based
+	PageSize int
+	// Sort lists the columns to order by, most significant first. Empty
+	// means the endpoint's default order.
+	Sort []SortField
+}
+
+// Offset returns how many rows precede the page
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// Option configures Parse
+type Option func(*options)
+
+type options struct {
+	defaultPageSize int
+	maxPageSize     int
+	sortable        map[string]struct{}
+	sortableList    string
+}
+
+// WithDefaultPageSize sets the page size used when page_size is absent
+func WithDefaultPageSize(n int) Option {
+	return func(o *options) {
+		o.defaultPageSize = n
+	}
+}
+
+// WithMaxPageSize sets the largest page size; larger requests are clamped
+func WithMaxPageSize(n int) Option {
+	return func(o *options) {
+		o.maxPageSize = n
+	}
+}
+
+// WithSortable lists the columns sort may name. Without it any sort is
+// rejected. Columns end up in SQL, so never take them from the request.
+func WithSortable(columns ...string) Option {
+	return func(o *options) {
+		for _, col := range columns {
+			o.sortable[col] = struct{}{}
+		}
+		o.sortableList = strings.Join(columns, " ")
+	}
+}
+
+// Parse reads page, page_size and sort from the query string. page and
+// page_size must be positive integers, and page_size is clamped to the
+// maximum. sort is a comma-separated list of sortable columns, each
+// prefixed with "-" for descending order, e.g. "-created_at,name". Invalid
+// parameters yield an ErrInvalidParams error with a detail per field.
+func Parse(c *gin.Context, opts ...Option) (Params, error) {
+	o := options{
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     defaultMaxPageSize,
+		sortable:        make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var details []errors.FieldError
+	page, fe := positiveInt(c, "page", 1)
+	if fe != nil {
+		details = append(details, *fe)
+	}
+	pageSize, fe := positiveInt(c, "page_size", o.defaultPageSize)
+	if fe != nil {
+		details = append(details, *fe)
+	}
+	sort, fe := o.parseSort(c.Query("sort"))
+	if fe != nil {
+		details = append(details, *fe)
+	}
+	if len(details) > 0 {
+		return Params{}, errors.InvalidParams(details...)
+	}
+
+	return Params{Page: page, PageSize: min(pageSize, o.maxPageSize), Sort: sort}, nil
+}
+
+// positiveInt reads the query parameter key, or def if it is absent
+func positiveInt(c *gin.Context, key string, def int) (int, *errors.FieldError) {
+	raw, ok := c.GetQuery(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &errors.FieldError{Field: key, Tag: "type", Message: key + " must be of type int"}
+	}
+	if n < 1 {
+		return 0, &errors.FieldError{Field: key, Tag: "min", Message: key + " must be at least 1"}
+	}
+	return n, nil
+}
+
+func (o *options) parseSort(raw string) ([]SortField, *errors.FieldError) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		column, desc := strings.CutPrefix(part, "-")
+		if _, ok := o.sortable[column]; !ok {
+			return nil, &errors.FieldError{
+				Field:   "sort",
+				Tag:     "oneof",
+				Message: fmt.Sprintf("sort column %q must be one of: %s", column, o.sortableList),
+			}
+		}
+		if seen[column] {
+			return nil, &errors.FieldError{
+				Field:   "sort",
+				Tag:     "unique",
+				Message: fmt.Sprintf("sort lists %s more than once", column),
+			}
+		}
+		seen[column] = true
+		fields = append(fields, SortField{Column: column, Desc: desc})
+	}
+	return fields, nil
+}
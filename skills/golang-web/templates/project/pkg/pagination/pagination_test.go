@@ -0,0 +1,63 @@
+// pkg/pagination/pagination_test.go
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/errors"
+)
+
+func TestParse(t *testing.T) {
+	opts := []Option{WithMaxPageSize(50), WithSortable("created_at", "name")}
+
+	tests := []struct {
+		query   string
+		want    Params
+		invalid []string // fields reported as invalid
+	}{
+		{query: "", want: Params{Page: 1, PageSize: 20}},
+		{query: "page=3&page_size=10", want: Params{Page: 3, PageSize: 10}},
+		{query: "page_size=500", want: Params{Page: 1, PageSize: 50}},
+		{
+			query: "sort=-created_at,name",
+			want:  Params{Page: 1, PageSize: 20, Sort: []SortField{{"created_at", true}, {"name", false}}},
+		},
+		{query: "page=0", invalid: []string{"page"}},
+		{query: "page=x&page_size=-1", invalid: []string{"page", "page_size"}},
+		{query: "sort=password", invalid: []string{"sort"}},
+		{query: "sort=name,-name", invalid: []string{"sort"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			got, err := Parse(c, opts...)
+			if tt.invalid == nil {
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Parse = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+
+			var appErr *errors.AppError
+			if !errors.As(err, &appErr) || appErr.Kind != errors.KindValidation {
+				t.Fatalf("error = %v, want a KindValidation AppError", err)
+			}
+			var fields []string
+			for _, d := range appErr.Details {
+				fields = append(fields, d.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.invalid) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.invalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// pkg/response/naming.go
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Field namings selectable with SetFieldNaming
+const (
+	FieldNamingSnake = "snake_case" // keys as tagged, e.g. created_at
+	FieldNamingCamel = "camel_case" // e.g. createdAt
+)
+
+// renameKey rewrites object keys, or is nil to keep them as tagged
+var renameKey func(string) string
+
+// sortedMsgpackHandle writes maps with their keys sorted, for documents
+// whose key order was lost to renaming
+var sortedMsgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.Canonical = true
+	return h
+}()
+
+// SetFieldNaming selects how object keys are written in JSON and
+// MessagePack responses, envelopes and payloads alike. Tags are written in
+// snake_case, so that naming leaves them untouched. Call it once at
+// startup.
+func SetFieldNaming(naming string) error {
+	switch naming {
+	case FieldNamingSnake, "":
+		renameKey = nil
+	case FieldNamingCamel:
+		renameKey = snakeToCamel
+	default:
+		return fmt.Errorf("unknown field naming: %s (supported: snake_case, camel_case)", naming)
+	}
+	return nil
+}
+
+// marshalJSON encodes v as JSON with keys renamed per SetFieldNaming
+func marshalJSON(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || renameKey == nil {
+		return b, err
+	}
+	return renameKeys(b, renameKey)
+}
+
+// marshalMsgpack encodes v as MessagePack with keys renamed per
+// SetFieldNaming. Renaming goes through a generic decoding, so renamed
+// maps come out with their keys sorted rather than in field order.
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var b []byte
+	if err := codec.NewEncoderBytes(&b, msgpackHandle).Encode(v); err != nil || renameKey == nil {
+		return b, err
+	}
+	var doc interface{}
+	if err := codec.NewDecoderBytes(b, msgpackHandle).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var out []byte
+	err := codec.NewEncoderBytes(&out, sortedMsgpackHandle).Encode(renameMapKeys(doc, renameKey))
+	return out, err
+}
+
+// renameMapKeys rewrites the string keys of every map in the decoded
+// MessagePack document v
+func renameMapKeys(v interface{}, rename func(string) string) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(t))
+		for k, val := range t {
+			if s, ok := k.(string); ok {
+				k = rename(s)
+			}
+			out[k] = renameMapKeys(val, rename)
+		}
+		return out
+	case []interface{}:
+		for i := range t {
+			t[i] = renameMapKeys(t[i], rename)
+		}
+	}
+	return v
+}
+
+// renameKeys rewrites every object key in the JSON document data,
+// preserving key order and leaving values as they are
+func renameKeys(data []byte, rename func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// Per open container: whether it is an object, and tokens seen in it
+	type container struct {
+		object bool
+		n      int
+	}
+	var stack []container
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(d))
+			continue
+		}
+
+		// Separator before the token, and key renaming
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			isKey := top.object && top.n%2 == 0
+			switch {
+			case top.object && !isKey:
+				out.WriteByte(':')
+			case top.n > 0:
+				out.WriteByte(',')
+			}
+			top.n++
+			if isKey {
+				tok = rename(tok.(string))
+			}
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(t))
+			stack = append(stack, container{object: t == '{'})
+		case json.Number:
+			out.WriteString(t.String())
+		default:
+			b, err := json.Marshal(t)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(b)
+		}
+	}
+}
+
+// snakeToCamel converts e.g. created_at to createdAt. Leading and doubled
+// underscores are kept, so only ordinary snake_case names change.
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	upper := false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '_' && i > 0 && i+1 < len(s) && s[i-1] != '_' && s[i+1] != '_':
+			upper = true
+		case upper && 'a' <= ch && ch <= 'z':
+			b.WriteByte(ch - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(ch)
+			upper = false
+		}
+	}
+	return b.String()
+}
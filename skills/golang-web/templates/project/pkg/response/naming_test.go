@@ -0,0 +1,95 @@
+// pkg/response/naming_test.go
+package response
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+func TestSnakeToCamel(t *testing.T) {
+	tests := map[string]string{
+		"id":            "id",
+		"created_at":    "createdAt",
+		"total_pages":   "totalPages",
+		"api_key_id":    "apiKeyId",
+		"_internal":     "_internal",
+		"trailing_":     "trailing_",
+		"double__under": "double__under",
+		"Already_Upper": "AlreadyUpper",
+	}
+	for in, want := range tests {
+		if got := snakeToCamel(in); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	in := `{"page_size":20,"data":[{"created_at":"2024-01-02T03:04:05Z","user_name":"a_b"},[1.50,true,null]],"next_cursor":"x","nested":{"deep_key":{}}}`
+	want := `{"pageSize":20,"data":[{"createdAt":"2024-01-02T03:04:05Z","userName":"a_b"},[1.50,true,null]],"nextCursor":"x","nested":{"deepKey":{}}}`
+
+	got, err := renameKeys([]byte(in), snakeToCamel)
+	if err != nil {
+		t.Fatalf("renameKeys: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("renameKeys =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSetFieldNaming(t *testing.T) {
+	t.Cleanup(func() { _ = SetFieldNaming(FieldNamingSnake) })
+
+	if err := SetFieldNaming("kebab-case"); err == nil {
+		t.Error("SetFieldNaming accepted an unknown naming")
+	}
+
+	if err := SetFieldNaming(FieldNamingCamel); err != nil {
+		t.Fatalf("SetFieldNaming: %v", err)
+	}
+	b, err := marshalJSON(Response{Code: 404, Message: "not found", RequestID: "r1"})
+	if err != nil {
+		t.Fatalf("marshalJSON: %v", err)
+	}
+	if want := `{"code":404,"message":"not found","requestId":"r1"}`; string(b) != want {
+		t.Errorf("envelope = %s, want %s", b, want)
+	}
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	t.Cleanup(func() { _ = SetFieldNaming(FieldNamingSnake) })
+	if err := SetFieldNaming(FieldNamingCamel); err != nil {
+		t.Fatalf("SetFieldNaming: %v", err)
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b, err := marshalMsgpack(Response{
+		Code: 200,
+		Data: map[string]interface{}{"created_at": created, "raw_bytes": []byte{1, 2}, "items": []interface{}{map[string]int{"page_size": 20}}},
+	})
+	if err != nil {
+		t.Fatalf("marshalMsgpack: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := codec.NewDecoderBytes(b, msgpackHandle).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	data, ok := got["data"].(map[interface{}]interface{})
+	if !ok {
+		t.Fatalf("data = %#v, want a map", got["data"])
+	}
+	if at, ok := data["createdAt"].(time.Time); !ok || !at.Equal(created) {
+		t.Errorf("createdAt = %#v, want %v", data["createdAt"], created)
+	}
+	if raw, ok := data["rawBytes"].([]byte); !ok || !bytes.Equal(raw, []byte{1, 2}) {
+		t.Errorf("rawBytes = %#v, want [1 2]", data["rawBytes"])
+	}
+	item := data["items"].([]interface{})[0].(map[interface{}]interface{})
+	if _, ok := item["pageSize"]; !ok {
+		t.Errorf("items[0] = %#v, want key pageSize", item)
+	}
+}
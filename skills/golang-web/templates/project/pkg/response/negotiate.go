@@ -0,0 +1,68 @@
+// pkg/response/negotiate.go
+package response
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+// offered lists the response encodings in order of preference. JSON comes
+// first so requests without an Accept header get JSON.
+var offered = []string{binding.MIMEJSON, binding.MIMEMSGPACK, binding.MIMEMSGPACK2}
+
+// msgpackHandle encodes with json tag names, str8 strings and the standard
+// timestamp extension, so MessagePack mirrors the JSON envelope
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// write encodes obj in the format the client's Accept header prefers,
+// falling back to JSON
+func write(c *gin.Context, status int, obj interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	switch c.NegotiateFormat(offered...) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(status, msgpackRender{data: obj})
+	default:
+		writeJSON(c, status, obj)
+	}
+}
+
+// writeJSON encodes obj as JSON with the configured field naming
+func writeJSON(c *gin.Context, status int, obj interface{}) {
+	if renameKey == nil {
+		c.JSON(status, obj)
+		return
+	}
+	b, err := marshalJSON(obj)
+	if err != nil {
+		// Let gin report the encoding error as it would have
+		c.JSON(status, obj)
+		return
+	}
+	c.Data(status, "application/json; charset=utf-8", b)
+}
+
+// msgpackRender is a gin render.Render for MessagePack
+type msgpackRender struct {
+	data interface{}
+}
+
+func (r msgpackRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if renameKey == nil {
+		return codec.NewEncoder(w, msgpackHandle).Encode(r.data)
+	}
+	b, err := marshalMsgpack(r.data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (r msgpackRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", binding.MIMEMSGPACK2)
+}
@@ -2,64 +2,249 @@
 package response
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/alert"
+	"github.com/yourname/myapp/pkg/ctxkeys"
 	apperrors "github.com/yourname/myapp/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Response represents a unified API response
+// Response represents a unified API response. Error responses carry the
+// request ID, and the trace ID when tracing is on, so clients can quote
+// them when reporting a failure.
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	TraceID   string      `json:"trace_id,omitempty"`
+}
+
+// PaginatedResponse represents a page of a list with pagination metadata
+type PaginatedResponse struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// CursorResponse represents a page of a list iterated by cursor.
+// NextCursor is omitted on the last page.
+type CursorResponse struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// Task identifies an operation that continues after an Accepted response.
+// Location is where its status can be polled.
+type Task struct {
+	TaskID   string `json:"task_id"`
+	Location string `json:"location"`
+}
+
+// ItemError reports why one element of a batch request failed
+type ItemError struct {
+	Index   int                    `json:"index"`
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Details []apperrors.FieldError `json:"details,omitempty" swaggertype:"array,object"`
+}
+
+// JSON sends obj as is, without the envelope, e.g. for health reports.
+// Keys follow SetFieldNaming like every other response.
+func JSON(c *gin.Context, status int, obj interface{}) {
+	write(c, status, obj)
 }
 
 // Success sends a success response
 func Success(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, Response{
+	write(c, http.StatusOK, Response{
 		Code:    0,
 		Message: "success",
 		Data:    data,
 	})
 }
 
+// Paginated sends a success response for a page of a list
+func Paginated(c *gin.Context, items interface{}, total int64, page, pageSize int) {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	write(c, http.StatusOK, PaginatedResponse{
+		Code:       0,
+		Message:    "success",
+		Data:       items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	})
+}
+
+// CursorPaginated sends a success response for a page of a list iterated
+// by cursor
+func CursorPaginated(c *gin.Context, items interface{}, nextCursor string) {
+	write(c, http.StatusOK, CursorResponse{
+		Code:       0,
+		Message:    "success",
+		Data:       items,
+		NextCursor: nextCursor,
+	})
+}
+
+// WeakETag builds a weak entity tag from values that change whenever the
+// resource does, such as its ID and update time
+func WeakETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified sets the ETag header and, if the request's If-None-Match
+// matches etag, sends 304 and returns true. Callers return early on true.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		// If-None-Match uses weak comparison
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
 // Created sends a 201 created response
 func Created(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusCreated, Response{
+	write(c, http.StatusCreated, Response{
 		Code:    0,
 		Message: "created",
 		Data:    data,
 	})
 }
 
+// Accepted sends a 202 accepted response for work that goes on in the
+// background, with a Location header pointing to location, the endpoint
+// reporting the status of task taskID
+func Accepted(c *gin.Context, taskID, location string) {
+	c.Header("Location", location)
+	write(c, http.StatusAccepted, Response{
+		Code:    0,
+		Message: "accepted",
+		Data:    Task{TaskID: taskID, Location: location},
+	})
+}
+
 // NoContent sends a 204 no content response
 func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// Error sends an error response
+// Error sends an error response. Server errors are logged in full with the
+// request ID and, except 503s, sent to the alert reporter; clients only see
+// the generic message.
 func Error(c *gin.Context, err error) {
+	// A canceled context or a deadline anywhere in the chain means the
+	// client left or the request ran out of time, whatever layer wrapped
+	// it, so neither counts as a server error
+	if ctxErr := apperrors.FromContext(err); ctxErr != nil {
+		err = ctxErr
+	}
+	// Likewise for an outage of a dependency such as the database
+	if errors.Is(err, apperrors.ErrUnavailable) {
+		err = apperrors.ErrUnavailable
+	}
+
+	status := http.StatusInternalServerError
+	resp := Response{
+		Code:    500,
+		Message: "internal server error",
+	}
 	var appErr *apperrors.AppError
 	if errors.As(err, &appErr) {
-		c.JSON(appErr.HTTPStatus(), Response{
+		status = appErr.HTTPStatus()
+		resp.Code = appErr.Code
+		resp.Message = appErr.Message
+		if len(appErr.Details) > 0 {
+			resp.Details = appErr.Details
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		// A sentinel returned as it is has no stack; this is the closest
+		// to where it happened
+		if err == error(appErr) && appErr.StackTrace() == nil {
+			err = appErr.WithStack()
+		}
+		ctx := c.Request.Context()
+		requestID := ctxkeys.RequestID(ctx)
+		slog.LogAttrs(ctx, slog.LevelError, "request failed",
+			slog.String("error", fmt.Sprintf("%+v", err)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("request_id", requestID),
+		)
+		// A dependency outage or maintenance would report every request
+		if status != http.StatusServiceUnavailable {
+			alert.Report(ctx, alert.Event{Err: err, Request: c.Request, RequestID: requestID})
+		}
+	}
+	fail(c, status, resp)
+}
+
+// BatchError sends a per-item report for a rejected batch. errs is indexed
+// like the request and nil entries are skipped. The status comes from the
+// first failed item; internal errors are reported as a plain Error instead.
+func BatchError(c *gin.Context, errs []error) {
+	status := http.StatusBadRequest
+	items := make([]ItemError, 0, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || appErr.Kind == apperrors.KindInternal {
+			Error(c, err)
+			return
+		}
+		if len(items) == 0 {
+			status = appErr.HTTPStatus()
+		}
+		items = append(items, ItemError{
+			Index:   i,
 			Code:    appErr.Code,
 			Message: appErr.Message,
+			Details: appErr.Details,
 		})
-		return
 	}
 
-	// Unknown error
-	c.JSON(http.StatusInternalServerError, Response{
-		Code:    500,
-		Message: "internal server error",
+	fail(c, status, Response{
+		Code:    status,
+		Message: fmt.Sprintf("%d of %d items failed", len(items), len(errs)),
+		Details: items,
 	})
 }
 
 // ErrorWithMessage sends an error response with custom message
 func ErrorWithMessage(c *gin.Context, status int, code int, message string) {
-	c.JSON(status, Response{
+	fail(c, status, Response{
 		Code:    code,
 		Message: message,
 	})
@@ -67,7 +252,7 @@ func ErrorWithMessage(c *gin.Context, status int, code int, message string) {
 
 // BadRequest sends a 400 bad request response
 func BadRequest(c *gin.Context, message string) {
-	c.JSON(http.StatusBadRequest, Response{
+	fail(c, http.StatusBadRequest, Response{
 		Code:    400,
 		Message: message,
 	})
@@ -75,7 +260,7 @@ func BadRequest(c *gin.Context, message string) {
 
 // Unauthorized sends a 401 unauthorized response
 func Unauthorized(c *gin.Context, message string) {
-	c.JSON(http.StatusUnauthorized, Response{
+	fail(c, http.StatusUnauthorized, Response{
 		Code:    401,
 		Message: message,
 	})
@@ -83,8 +268,18 @@ func Unauthorized(c *gin.Context, message string) {
 
 // NotFound sends a 404 not found response
 func NotFound(c *gin.Context, message string) {
-	c.JSON(http.StatusNotFound, Response{
+	fail(c, http.StatusNotFound, Response{
 		Code:    404,
 		Message: message,
 	})
 }
+
+// fail sends an error response tagged with the request and trace IDs
+func fail(c *gin.Context, status int, resp Response) {
+	ctx := c.Request.Context()
+	resp.RequestID = ctxkeys.RequestID(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		resp.TraceID = sc.TraceID().String()
+	}
+	write(c, status, resp)
+}
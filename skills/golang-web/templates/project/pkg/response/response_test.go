@@ -0,0 +1,97 @@
+// pkg/response/response_test.go
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourname/myapp/pkg/alert"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+)
+
+func TestErrorMapsContextErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		// As a service reports a failed query
+		{"canceled", apperrors.Wrap(fmt.Errorf("query: %w", context.Canceled), apperrors.KindInternal, "failed to get user"), apperrors.StatusClientClosedRequest},
+		{"deadline", apperrors.Wrap(context.DeadlineExceeded, apperrors.KindInternal, "failed to get user"), http.StatusGatewayTimeout},
+		{"other", apperrors.Wrap(fmt.Errorf("disk full"), apperrors.KindInternal, "failed to get user"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			Error(c, tt.err)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/imports", nil)
+
+	Accepted(c, "t1", "/api/v1/tasks/t1")
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if got := w.Header().Get("Location"); got != "/api/v1/tasks/t1" {
+		t.Errorf("Location = %q, want /api/v1/tasks/t1", got)
+	}
+
+	var body struct {
+		Data Task `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Data != (Task{TaskID: "t1", Location: "/api/v1/tasks/t1"}) {
+		t.Errorf("data = %+v, want task t1", body.Data)
+	}
+}
+
+type recordingReporter struct {
+	events []alert.Event
+}
+
+func (r *recordingReporter) Report(_ context.Context, e alert.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestErrorReportsServerErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := &recordingReporter{}
+	alert.SetReporter(rec)
+	t.Cleanup(func() { alert.SetReporter(alert.Nop{}) })
+
+	for _, err := range []error{
+		apperrors.Wrap(fmt.Errorf("disk full"), apperrors.KindInternal, "failed to save user"),
+		apperrors.ErrNotFound,
+		apperrors.ErrUnavailable,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		Error(c, err)
+	}
+
+	if len(rec.events) != 1 || rec.events[0].Request == nil {
+		t.Fatalf("reported %+v, want only the internal error", rec.events)
+	}
+}
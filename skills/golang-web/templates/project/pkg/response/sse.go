@@ -0,0 +1,140 @@
+// pkg/response/sse.go
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrHeadersSent is returned by SSE when the response has already started
+var ErrHeadersSent = errors.New("response headers already sent")
+
+// Event is a server-sent event. Data strings and byte slices are sent as
+// is, anything else as JSON; multi-line data is split across data fields.
+// Event and ID are optional; Retry, if set, tells the client how long to
+// wait before reconnecting.
+type Event struct {
+	Event string
+	ID    string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// SSEWriter streams server-sent events to a client, flushing after each
+// one. Writes fail with the context's error once the client disconnects
+// or the request context ends.
+type SSEWriter struct {
+	w   gin.ResponseWriter
+	ctx context.Context
+}
+
+// SSE starts an event stream response. The server's write timeout is
+// lifted for the stream, but it still ends with the request context, so
+// long-lived streams belong on routes without a request timeout.
+func SSE(c *gin.Context) (*SSEWriter, error) {
+	ctx := c.Request.Context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.Writer.Written() {
+		return nil, ErrHeadersSent
+	}
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	h.Set("X-Accel-Buffering", "no")
+
+	// Not every writer supports deadlines, e.g. in tests
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	return &SSEWriter{w: c.Writer, ctx: ctx}, nil
+}
+
+// Done is closed when the client disconnects or the request context ends
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send writes e and flushes it to the client
+func (s *SSEWriter) Send(e Event) error {
+	if strings.ContainsAny(e.Event, "\r\n") || strings.ContainsAny(e.ID, "\r\n") {
+		return errors.New("sse: event name and id must be a single line")
+	}
+	data, err := eventData(e.Data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Data sends an unnamed event carrying data
+func (s *SSEWriter) Data(data interface{}) error {
+	return s.Send(Event{Data: data})
+}
+
+// Comment sends a comment line, which clients ignore. Sent periodically it
+// keeps idle streams from being closed by proxies.
+func (s *SSEWriter) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+func (s *SSEWriter) write(frame string) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.WriteString(frame); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+// eventData renders an event's data field
+func eventData(data interface{}) (string, error) {
+	switch d := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return d, nil
+	case []byte:
+		return string(d), nil
+	default:
+		b, err := marshalJSON(d)
+		if err != nil {
+			return "", fmt.Errorf("sse: failed to encode data: %w", err)
+		}
+		return string(b), nil
+	}
+}
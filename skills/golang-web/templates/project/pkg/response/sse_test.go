@@ -0,0 +1,94 @@
+// pkg/response/sse_test.go
+package response
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSSEFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/events", func(c *gin.Context) {
+		sse, err := SSE(c)
+		if err != nil {
+			t.Errorf("SSE: %v", err)
+			return
+		}
+		_ = sse.Send(Event{Event: "progress", ID: "7", Data: map[string]int{"done": 3}, Retry: 2 * time.Second})
+		_ = sse.Data("line one\nline two")
+		_ = sse.Comment("keepalive")
+		if err := sse.Send(Event{Event: "bad\nname"}); err == nil {
+			t.Error("Send accepted an event name with a newline")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if !w.Flushed {
+		t.Error("events were not flushed")
+	}
+	want := "event: progress\nid: 7\nretry: 2000\ndata: {\"done\":3}\n\n" +
+		"data: line one\ndata: line two\n\n" +
+		": keepalive\n\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestSSEStopsOnDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stopped := make(chan error, 1)
+	r := gin.New()
+	r.GET("/events", func(c *gin.Context) {
+		sse, err := SSE(c)
+		if err != nil {
+			stopped <- err
+			return
+		}
+		for {
+			if err := sse.Data("tick"); err != nil {
+				stopped <- err
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	// The first event arrives before the stream ends
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "data: tick\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	cancel()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	select {
+	case err := <-stopped:
+		if err == nil {
+			t.Error("handler stopped without an error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler kept writing after the client disconnected")
+	}
+}
@@ -0,0 +1,106 @@
+// pkg/response/stream.go
+package response
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamFlushEvery is how many items JSONStream writes between flushes
+const streamFlushEvery = 100
+
+// JSONStream writes a JSON array, or newline-delimited JSON, one item at a
+// time, so a response of any size takes no more memory than one item.
+// Items are not wrapped in a Response.
+type JSONStream struct {
+	c       *gin.Context
+	w       gin.ResponseWriter
+	ctx     context.Context
+	ndjson  bool
+	started bool
+	n       int
+}
+
+// StreamJSON starts a streamed response of JSON items: an array, or with
+// ndjson one item per line. Nothing is sent or set, headers included,
+// until the first Write, so an error can still be reported normally before
+// then. Once items are sent a failure can only cut the body short; leave
+// the stream unclosed then, so an array is visibly truncated rather than
+// complete. As with SSE, the server's write timeout is lifted for the
+// stream.
+func StreamJSON(c *gin.Context, ndjson bool) (*JSONStream, error) {
+	ctx := c.Request.Context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.Writer.Written() {
+		return nil, ErrHeadersSent
+	}
+
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	return &JSONStream{c: c, w: c.Writer, ctx: ctx, ndjson: ndjson}, nil
+}
+
+// start sets the stream's headers before its first bytes
+func (s *JSONStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	if s.ndjson {
+		s.c.Header("Content-Type", "application/x-ndjson")
+	} else {
+		s.c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	s.c.Status(http.StatusOK)
+}
+
+// Write sends v as the next item. It fails with the context's error once
+// the client disconnects or the request context ends.
+func (s *JSONStream) Write(v interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	b, err := marshalJSON(v)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case s.ndjson:
+		b = append(b, '\n')
+	case s.n == 0:
+		b = append([]byte{'['}, b...)
+	default:
+		b = append([]byte{','}, b...)
+	}
+	s.start()
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+
+	s.n++
+	if s.n%streamFlushEvery == 0 {
+		s.w.Flush()
+	}
+	return nil
+}
+
+// Close ends the stream, completing the array
+func (s *JSONStream) Close() error {
+	s.start()
+	if !s.ndjson {
+		tail := "]"
+		if s.n == 0 {
+			tail = "[]"
+		}
+		if _, err := s.w.WriteString(tail); err != nil {
+			return err
+		}
+	}
+	s.w.Flush()
+	return nil
+}
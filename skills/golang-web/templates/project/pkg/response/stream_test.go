@@ -0,0 +1,63 @@
+// pkg/response/stream_test.go
+package response
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/yourname/myapp/pkg/errors"
+)
+
+func TestStreamJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		ndjson      bool
+		items       []int
+		fail        bool
+		status      int
+		contentType string
+		body        string
+	}{
+		{"array", false, []int{1, 2}, false, http.StatusOK, "application/json; charset=utf-8", "[1,2]"},
+		{"empty array", false, nil, false, http.StatusOK, "application/json; charset=utf-8", "[]"},
+		{"ndjson", true, []int{1, 2}, false, http.StatusOK, "application/x-ndjson", "1\n2\n"},
+		{"error before the first item", true, nil, true, http.StatusInternalServerError, "application/json; charset=utf-8", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			stream, err := StreamJSON(c, tt.ndjson)
+			if err != nil {
+				t.Fatalf("StreamJSON: %v", err)
+			}
+			for _, item := range tt.items {
+				if err := stream.Write(item); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if tt.fail {
+				Error(c, apperrors.Wrap(errors.New("query failed"), apperrors.KindInternal, "failed to export"))
+			} else if err := stream.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
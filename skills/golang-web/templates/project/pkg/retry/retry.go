@@ -0,0 +1,102 @@
+// pkg/retry/retry.go
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/yourname/myapp/configs"
+)
+
+// Policy controls how often and how fast an operation is retried
+type Policy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Retryable reports whether err is transient. Nil retries every error.
+	// Context cancellation and deadline errors are never retried.
+	Retryable func(error) bool
+}
+
+// FromConfig builds a Policy from config, using retryable to classify errors
+func FromConfig(cfg configs.RetryConfig, retryable func(error) bool) Policy {
+	return Policy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     2,
+		Retryable:      retryable,
+	}
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, or
+// MaxAttempts is reached, sleeping with exponential backoff and jitter
+// between attempts. It returns the last error from fn.
+func Do(ctx context.Context, p Policy, fn func() error) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(jitter(backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff = time.Duration(float64(backoff) * p.multiplier())
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// Backoff returns how long to wait after the given number of failed
+// attempts, growing exponentially from InitialBackoff up to MaxBackoff,
+// with jitter. Use it to schedule retries that outlive a single Do call.
+func (p Policy) Backoff(failures int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < failures; i++ {
+		backoff = time.Duration(float64(backoff) * p.multiplier())
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+			break
+		}
+	}
+	return jitter(backoff)
+}
+
+func (p Policy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+func (p Policy) multiplier() float64 {
+	if p.Multiplier < 1 {
+		return 2
+	}
+	return p.Multiplier
+}
+
+// jitter returns a random duration in [d/2, d) so concurrent callers
+// do not retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
+}
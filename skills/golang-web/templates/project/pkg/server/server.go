@@ -3,27 +3,55 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"golang.org/x/crypto/acme/autocert"
 )
 
+// autocertCacheDir is where Let's Encrypt certificates are persisted
+const autocertCacheDir = "certs"
+
+// ErrListen is returned by Run when the server cannot bind its port
+var ErrListen = errors.New("failed to listen")
+
+// ErrTLSFiles is returned by Run when WithTLS was given a certificate
+// without a key or the other way round
+var ErrTLSFiles = errors.New("TLS needs both a certificate and a key file")
+
 // Server represents an HTTP server with graceful shutdown
 type Server struct {
-	port         int
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	handler      http.Handler
+	port              int
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+	shutdownDelay     time.Duration
+	shutdownTimeout   time.Duration
+	handler           http.Handler
+	certFile          string
+	keyFile           string
+	autoTLSDomains    []string
+	onReady           []func(net.Addr)
+	onShutdownStart   []func()
+	onShutdown        []func(context.Context) error
+	onReload          []func()
+	inFlight          atomic.Int64
 }
 
 // Option is a functional option for Server
 type Option func(*Server)
 
-// WithPort sets the server port
+// WithPort sets the server port. Zero keeps the default (8080, or 443 with TLS)
 func WithPort(port int) Option {
 	return func(s *Server) {
 		s.port = port
@@ -37,6 +65,17 @@ func WithReadTimeout(d time.Duration) Option {
 	}
 }
 
+// WithReadHeaderTimeout bounds how long a client may take to send request
+// headers, so slow senders cannot hold connections open. Zero keeps the
+// default of 5s.
+func WithReadHeaderTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.readHeaderTimeout = d
+		}
+	}
+}
+
 // WithWriteTimeout sets the write timeout
 func WithWriteTimeout(d time.Duration) Option {
 	return func(s *Server) {
@@ -44,59 +83,274 @@ func WithWriteTimeout(d time.Duration) Option {
 	}
 }
 
+// WithIdleTimeout sets how long a keep-alive connection may wait for its
+// next request. Zero keeps the default of 2m.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.idleTimeout = d
+		}
+	}
+}
+
+// WithMaxHeaderBytes caps the size of request headers, including the
+// request line. Zero keeps the default of 1 MiB.
+func WithMaxHeaderBytes(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxHeaderBytes = n
+		}
+	}
+}
+
+// WithShutdownTimeout sets how long in-flight requests may drain on shutdown
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// WithShutdownDelay keeps serving for d after a shutdown signal, once the
+// WithOnShutdownStart callbacks have failed readiness, so load balancers
+// can deregister the instance before connections start being refused. A
+// second signal cuts the delay short.
+func WithShutdownDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownDelay = d
+	}
+}
+
+// WithOnReady registers a callback run once the server is listening,
+// with the bound address
+func WithOnReady(fn func(addr net.Addr)) Option {
+	return func(s *Server) {
+		s.onReady = append(s.onReady, fn)
+	}
+}
+
+// WithOnShutdownStart registers a callback run as soon as a shutdown signal
+// is received, before in-flight requests are drained. Use it to fail
+// readiness probes so load balancers stop routing new traffic.
+func WithOnShutdownStart(fn func()) Option {
+	return func(s *Server) {
+		s.onShutdownStart = append(s.onShutdownStart, fn)
+	}
+}
+
+// WithOnShutdown registers a cleanup hook run after the HTTP server stops.
+// Hooks run in reverse registration order and share the shutdown deadline.
+func WithOnShutdown(fn func(context.Context) error) Option {
+	return func(s *Server) {
+		s.onShutdown = append(s.onShutdown, fn)
+	}
+}
+
+// WithOnReload registers a callback run when the process receives SIGHUP,
+// conventionally a request to reload configuration. The server keeps
+// serving while it runs.
+func WithOnReload(fn func()) Option {
+	return func(s *Server) {
+		s.onReload = append(s.onReload, fn)
+	}
+}
+
+// WithTLS serves HTTPS using the given certificate and key files. Run
+// fails with ErrTLSFiles unless both are set.
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// WithAutoTLS serves HTTPS with certificates obtained from Let's Encrypt
+// for the given domains. Challenges are answered via TLS-ALPN-01, so the
+// server must be reachable on port 443.
+func WithAutoTLS(domains ...string) Option {
+	return func(s *Server) {
+		s.autoTLSDomains = domains
+	}
+}
+
 // New creates a new Server with options
 func New(handler http.Handler, opts ...Option) *Server {
 	s := &Server{
-		port:         8080,
-		readTimeout:  30 * time.Second,
-		writeTimeout: 30 * time.Second,
-		handler:      handler,
+		readTimeout:       30 * time.Second,
+		readHeaderTimeout: 5 * time.Second,
+		writeTimeout:      30 * time.Second,
+		idleTimeout:       2 * time.Minute,
+		maxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		shutdownTimeout:   10 * time.Second,
+		handler:           handler,
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	if s.port == 0 {
+		s.port = 8080
+		if s.tlsEnabled() {
+			s.port = 443
+		}
+	}
+
 	return s
 }
 
+func (s *Server) tlsEnabled() bool {
+	return len(s.autoTLSDomains) > 0 || (s.certFile != "" && s.keyFile != "")
+}
+
+// trackInFlight counts requests currently being served
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// drainPollInterval is how often waitDrained checks the in-flight count
+const drainPollInterval = 50 * time.Millisecond
+
+// waitDrained waits until no requests are in flight, reporting false if
+// ctx ends first. Shutdown already waits for ordinary requests, but not
+// for handlers still running on hijacked connections such as websockets.
+func (s *Server) waitDrained(ctx context.Context) bool {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for s.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
 // Run starts the server with graceful shutdown
 func (s *Server) Run() error {
+	if (s.certFile == "") != (s.keyFile == "") {
+		return ErrTLSFiles
+	}
+
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      s.handler,
-		ReadTimeout:  s.readTimeout,
-		WriteTimeout: s.writeTimeout,
+		Addr:              fmt.Sprintf(":%d", s.port),
+		Handler:           s.trackInFlight(s.handler),
+		ReadTimeout:       s.readTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
+	}
+
+	// Bind before serving so a taken port fails Run immediately
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrListen, err)
+	}
+
+	serve := func() error { return srv.Serve(ln) }
+	switch {
+	case len(s.autoTLSDomains) > 0:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.autoTLSDomains...),
+			Cache:      autocert.DirCache(autocertCacheDir),
+		}
+		srv.TLSConfig = m.TLSConfig()
+		serve = func() error { return srv.ServeTLS(ln, "", "") }
+	case s.tlsEnabled():
+		serve = func() error { return srv.ServeTLS(ln, s.certFile, s.keyFile) }
 	}
 
 	// Channel for server errors
 	errChan := make(chan error, 1)
 
+	slog.Info("server listening", "addr", ln.Addr().String(), "tls", s.tlsEnabled())
 	go func() {
-		slog.Info("server starting", "port", s.port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := serve(); err != nil && err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
 
+	for _, fn := range s.onReady {
+		fn(ln.Addr())
+	}
+
 	// Channel for OS signals
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
 
-	// Block until signal or error
-	select {
-	case err := <-errChan:
-		return fmt.Errorf("server error: %w", err)
-	case sig := <-quit:
-		slog.Info("shutdown signal received", "signal", sig)
+	// Block until a shutdown signal or error, reloading on SIGHUP
+wait:
+	for {
+		select {
+		case err := <-errChan:
+			return fmt.Errorf("server error: %w", err)
+		case <-reload:
+			slog.Info("reload signal received")
+			for _, fn := range s.onReload {
+				fn()
+			}
+		case sig := <-quit:
+			slog.Info("shutdown signal received",
+				"signal", sig,
+				"in_flight", s.inFlight.Load(),
+				"timeout", s.shutdownTimeout,
+			)
+			break wait
+		}
+	}
+
+	for _, fn := range s.onShutdownStart {
+		fn()
+	}
+
+	if s.shutdownDelay > 0 {
+		slog.Info("waiting for load balancers before draining", "delay", s.shutdownDelay)
+		timer := time.NewTimer(s.shutdownDelay)
+		select {
+		case <-timer.C:
+		case sig := <-quit:
+			timer.Stop()
+			slog.Info("second shutdown signal received, draining now", "signal", sig)
+		}
 	}
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
+	drainStart := time.Now()
+	var errs []error
 	if err := srv.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server shutdown error: %w", err)
+		errs = append(errs, fmt.Errorf("server shutdown error: %w", err))
+	}
+	if s.waitDrained(ctx) {
+		slog.Info("in-flight requests drained", "duration", time.Since(drainStart))
+	} else {
+		slog.Warn("shutdown deadline exceeded, abandoning in-flight requests",
+			"in_flight", s.inFlight.Load(),
+			"duration", time.Since(drainStart),
+		)
+	}
+
+	// Release resources in reverse order of acquisition
+	for i := len(s.onShutdown) - 1; i >= 0; i-- {
+		if err := s.onShutdown[i](ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook error: %w", err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
 
 	slog.Info("server stopped gracefully")
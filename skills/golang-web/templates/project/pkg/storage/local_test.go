@@ -0,0 +1,75 @@
+// pkg/storage/local_test.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(dir, "https://cdn.example.com/files/")
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	ctx := context.Background()
+
+	obj, err := store.Put(ctx, "avatars/u1", strings.NewReader("first"), 5, "text/plain")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if obj.URL != "https://cdn.example.com/files/avatars/u1" || obj.Size != 5 {
+		t.Errorf("object = %+v", obj)
+	}
+
+	// A second put replaces the file
+	if _, err := store.Put(ctx, "avatars/u1", strings.NewReader("second"), 6, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	rc, got, err := store.Get(ctx, "avatars/u1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "second" || got.Size != 6 {
+		t.Errorf("got %q (size %d), want second", body, got.Size)
+	}
+
+	// A short body leaves nothing behind
+	if _, err := store.Put(ctx, "avatars/u2", strings.NewReader("abc"), 10, "text/plain"); err == nil {
+		t.Error("Put of a short body succeeded")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "avatars")); len(entries) != 1 {
+		t.Errorf("avatars holds %d entries after a failed put, want 1", len(entries))
+	}
+
+	if err := store.Delete(ctx, "avatars/u1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "avatars/u1"); err != nil {
+		t.Errorf("Delete of a missing object: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "avatars/u1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", `a\b`} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain")
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
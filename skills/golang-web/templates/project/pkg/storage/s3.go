@@ -0,0 +1,134 @@
+// pkg/storage/s3.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 stores objects in an S3 bucket, or any S3-compatible store such as
+// MinIO, so every replica sees the same files. It implements Presigner.
+type S3 struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+	prefix  string
+	baseURL string
+}
+
+// NewS3 creates an S3 store putting objects in bucket, with prefix before
+// every key. URL returns baseURL followed by the prefixed key; point it at
+// the bucket's public endpoint or a CDN in front of it. For a private
+// bucket, hand out presigned URLs instead.
+func NewS3(client *s3.Client, bucket, prefix, baseURL string) *S3 {
+	return &S3{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  bucket,
+		prefix:  prefix,
+		baseURL: baseURL,
+	}
+}
+
+// Put needs r to be an io.ReadSeeker when the endpoint is plain HTTP, so
+// the payload can be signed; a multipart.File is one
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error) {
+	if err := checkKey(key); err != nil {
+		return Object{}, err
+	}
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.prefix + key),
+		Body:          r,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return Object{}, fmt.Errorf("storage put %s: %w", key, err)
+	}
+	return Object{Key: key, URL: s.URL(key), Size: size, ContentType: contentType}, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	if err := checkKey(key); err != nil {
+		return nil, Object{}, err
+	}
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	var noKey *types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, fmt.Errorf("storage get %s: %w", key, err)
+	}
+
+	obj := Object{
+		Key:         key,
+		URL:         s.URL(key),
+		Size:        aws.ToInt64(out.ContentLength),
+		ContentType: aws.ToString(out.ContentType),
+	}
+	return out.Body, obj, nil
+}
+
+// Delete succeeds for a missing object, as S3 itself does
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		return fmt.Errorf("storage delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) URL(key string) string {
+	return joinURL(s.baseURL, s.prefix+key)
+}
+
+// PresignGet signs with the client's credentials, so the URL stops working
+// early if they expire first, as instance role credentials do within hours
+func (s *S3) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("storage presign get %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// PresignPut returns a URL for an HTTP PUT, which must send contentType
+// as its Content-Type header
+func (s *S3) PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + key),
+		ContentType: aws.String(contentType),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("storage presign put %s: %w", key, err)
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,114 @@
+// pkg/storage/s3_test.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 serves path-style object requests from a map
+func fakeS3(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	types := make(map[string]string)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path], types[r.URL.Path] = body, r.Header.Get("Content-Type")
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`)
+				return
+			}
+			w.Header().Set("Content-Type", types[r.URL.Path])
+			w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestS3(endpoint string) *S3 {
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+		}),
+	})
+	return NewS3(client, "bucket", "app/", "https://cdn.example.com")
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	store := newTestS3(fakeS3(t).URL)
+	ctx := context.Background()
+
+	obj, err := store.Put(ctx, "exports/users.json", strings.NewReader("[]"), 2, "application/json")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if obj.URL != "https://cdn.example.com/app/exports/users.json" {
+		t.Errorf("url = %q", obj.URL)
+	}
+
+	rc, got, err := store.Get(ctx, "exports/users.json")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "[]" || got.ContentType != "application/json" {
+		t.Errorf("got %q as %q, want [] as application/json", body, got.ContentType)
+	}
+
+	if err := store.Delete(ctx, "exports/users.json"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "exports/users.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3Presign(t *testing.T) {
+	store := newTestS3("https://s3.example.com")
+
+	raw, err := store.PresignGet(context.Background(), "avatars/u1", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	q := u.Query()
+	if u.Path != "/bucket/app/avatars/u1" || q.Get("X-Amz-Expires") != "900" || q.Get("X-Amz-Signature") == "" {
+		t.Errorf("presigned url = %s", raw)
+	}
+
+	if _, err := store.PresignPut(context.Background(), "../escape", "image/png", time.Minute); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("PresignPut of an escaping key = %v, want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,72 @@
+// pkg/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidKey is returned for a key that is empty or would escape
+	// the store, such as one containing ".."
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrNotFound is returned by Get when no object has the key
+	ErrNotFound = errors.New("object not found")
+)
+
+// Storage keeps blobs such as uploaded files and exports, so handlers and
+// services never touch a vendor SDK. Local writes them to a directory; S3
+// puts them in a bucket. Keys are slash-separated paths like
+// "avatars/<id>/<hash>".
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any object
+	// already there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (Object, error)
+	// Get opens the object under key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete removes the object under key; a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients fetch the object under key, whether or not
+	// it exists
+	URL(key string) string
+}
+
+// Presigner is implemented by stores that can hand out temporary URLs, so
+// clients can fetch or upload private objects directly without the app
+// proxying the bytes
+type Presigner interface {
+	// PresignGet returns a URL that downloads key until ttl passes
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// PresignPut returns a URL that uploads key with contentType until
+	// ttl passes
+	PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error)
+}
+
+// Object references a stored file
+type Object struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// checkKey rejects keys that are empty, absolute or climb out of the store
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// joinURL appends key to base with exactly one slash between them
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
@@ -0,0 +1,89 @@
+// pkg/timing/timing.go
+package timing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Recorder sums the time a request spends in named phases, such as db.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	phases []*phase // in order of first use
+}
+
+type phase struct {
+	name  string
+	total time.Duration
+	count int
+}
+
+// WithRecorder returns a copy of ctx carrying a new Recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{}
+	return context.WithValue(ctx, contextKey{}, r), r
+}
+
+// FromContext returns the Recorder in ctx, or nil if there is none
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
+
+// Add records d against the phase name of the Recorder in ctx, if any
+func Add(ctx context.Context, name string, d time.Duration) {
+	if r := FromContext(ctx); r != nil {
+		r.Add(name, d)
+	}
+}
+
+// Track starts timing the phase name and returns a function that stops
+// it, for use as defer timing.Track(ctx, "db")()
+func Track(ctx context.Context, name string) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { r.Add(name, time.Since(start)) }
+}
+
+// Add records d against the phase name
+func (r *Recorder) Add(name string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.phases {
+		if p.name == name {
+			p.total += d
+			p.count++
+			return
+		}
+	}
+	r.phases = append(r.phases, &phase{name: name, total: d, count: 1})
+}
+
+// ServerTiming formats the recorded phases as a Server-Timing header
+// value, e.g. db;dur=1.25;desc="3 calls"
+func (r *Recorder) ServerTiming() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]string, 0, len(r.phases))
+	for _, p := range r.phases {
+		entries = append(entries, fmt.Sprintf(`%s;dur=%s;desc="%d calls"`, p.name, Milliseconds(p.total), p.count))
+	}
+	return strings.Join(entries, ", ")
+}
+
+// Milliseconds formats d in milliseconds with two decimals, as used by
+// Server-Timing and X-Response-Time
+func Milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.2f", float64(d.Microseconds())/1000)
+}
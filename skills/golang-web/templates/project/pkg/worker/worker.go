@@ -0,0 +1,178 @@
+// pkg/worker/worker.go
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the
+	// queue has no room. Callers shed the work or ask the client to retry.
+	ErrQueueFull = errors.New("worker: queue full")
+	// ErrStopped is returned for jobs submitted after Stop
+	ErrStopped = errors.New("worker: pool stopped")
+)
+
+// Job is a unit of background work. Its context is canceled when Stop
+// gives up waiting, so long jobs should watch it.
+type Job func(ctx context.Context) error
+
+// Option configures a Pool
+type Option func(*Pool)
+
+// WithWorkers sets how many jobs run at once
+func WithWorkers(n int) Option {
+	return func(p *Pool) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// WithQueueSize sets how many jobs may wait for a worker before Submit
+// reports ErrQueueFull
+func WithQueueSize(n int) Option {
+	return func(p *Pool) {
+		if n >= 0 {
+			p.queueSize = n
+		}
+	}
+}
+
+// Pool runs jobs on a fixed number of goroutines. Jobs are not persisted:
+// those still queued when the process dies are lost, so work that must
+// happen belongs in the outbox instead.
+type Pool struct {
+	workers   int
+	queueSize int
+
+	queue    chan Job
+	mu       sync.RWMutex // held for reading while sending to queue
+	closed   bool
+	quit     chan struct{}
+	stopOnce sync.Once
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	dropped  atomic.Int64
+}
+
+// New creates a Pool and starts its workers; call Stop on shutdown
+func New(opts ...Option) *Pool {
+	p := &Pool{workers: 4, queueSize: 100, quit: make(chan struct{})}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.queue = make(chan Job, p.queueSize)
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues job without blocking. It returns ErrQueueFull when the
+// queue is full, which is the pool's backpressure, and ErrStopped once
+// Stop has been called.
+func (p *Pool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrStopped
+	}
+
+	select {
+	case p.queue <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait queues job, waiting for room in the queue until ctx is done
+func (p *Pool) SubmitWait(ctx context.Context, job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrStopped
+	}
+
+	select {
+	case p.queue <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.quit:
+		return ErrStopped
+	}
+}
+
+// Queued returns how many jobs are waiting for a worker
+func (p *Pool) Queued() int {
+	return len(p.queue)
+}
+
+// Stop refuses new jobs and waits for the queued ones to finish. When ctx
+// expires first, running jobs are canceled and queued ones dropped.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		// Release senders blocked in SubmitWait, which hold mu
+		close(p.quit)
+		p.mu.Lock()
+		p.closed = true
+		close(p.queue)
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		if n := p.dropped.Load(); n > 0 {
+			slog.Warn("dropped queued background jobs on shutdown", "jobs", n)
+		}
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for job := range p.queue {
+		if p.ctx.Err() != nil {
+			p.dropped.Add(1)
+			continue
+		}
+		if err := p.run(job); err != nil {
+			slog.Error("background job failed", "error", err)
+		}
+	}
+}
+
+// run runs job, turning a panic into an error so one bad job does not take
+// the process down
+func (p *Pool) run(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return job(p.ctx)
+}
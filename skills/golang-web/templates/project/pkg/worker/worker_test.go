@@ -0,0 +1,120 @@
+// pkg/worker/worker_test.go
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStopDrainsQueue(t *testing.T) {
+	p := New(WithWorkers(2), WithQueueSize(10))
+
+	var ran atomic.Int64
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(func(context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			ran.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if got := ran.Load(); got != 10 {
+		t.Errorf("ran %d jobs, want 10", got)
+	}
+	if err := p.Submit(func(context.Context) error { return nil }); !errors.Is(err, ErrStopped) {
+		t.Errorf("Submit after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestSubmitReportsFullQueue(t *testing.T) {
+	p := New(WithWorkers(1), WithQueueSize(1))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	block := func(context.Context) error {
+		<-release
+		return nil
+	}
+
+	// One job running, one queued
+	if err := p.Submit(func(ctx context.Context) error {
+		close(started)
+		return block(ctx)
+	}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	if err := p.Submit(block); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := p.Submit(block); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit = %v, want ErrQueueFull", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.SubmitWait(ctx, block); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SubmitWait = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestStopDeadlineCancelsJobs(t *testing.T) {
+	p := New(WithWorkers(1), WithQueueSize(5))
+
+	var canceled, ran atomic.Int64
+	started := make(chan struct{})
+	if err := p.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		canceled.Add(1)
+		return ctx.Err()
+	}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	for i := 0; i < 3; i++ {
+		_ = p.Submit(func(context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop = %v, want context.DeadlineExceeded", err)
+	}
+	if canceled.Load() != 1 || ran.Load() != 0 {
+		t.Errorf("canceled %d, ran %d; want the running job canceled and queued ones dropped", canceled.Load(), ran.Load())
+	}
+}
+
+func TestJobPanicIsRecovered(t *testing.T) {
+	p := New(WithWorkers(1))
+
+	var ran atomic.Bool
+	_ = p.Submit(func(context.Context) error { panic("boom") })
+	_ = p.Submit(func(context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !ran.Load() {
+		t.Error("job after a panicking one did not run")
+	}
+}
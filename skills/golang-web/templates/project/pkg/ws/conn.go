@@ -0,0 +1,197 @@
+// pkg/ws/conn.go
+package ws
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message types and close codes, as defined by RFC 6455
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+
+	CloseNormalClosure = websocket.CloseNormalClosure
+	CloseGoingAway     = websocket.CloseGoingAway
+)
+
+var (
+	// ErrClosed is returned when sending on a closed connection
+	ErrClosed = errors.New("websocket closed")
+
+	// ErrSlowConsumer is returned when a client's send buffer is full; the
+	// connection is dropped
+	ErrSlowConsumer = errors.New("websocket client too slow")
+)
+
+// Message is a websocket data message
+type Message struct {
+	Type int
+	Data []byte
+}
+
+// Text returns a text message
+func Text(s string) Message {
+	return Message{Type: TextMessage, Data: []byte(s)}
+}
+
+// closeRequest is the close frame sent when the server closes a connection
+type closeRequest struct {
+	code int
+	text string
+}
+
+// Conn is a websocket connection registered with a Hub. Its methods are
+// safe for concurrent use.
+type Conn struct {
+	hub    *Hub
+	ws     *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	send      chan Message
+	closing   chan closeRequest
+	closeOnce sync.Once
+	closed    atomic.Bool   // set once the close frame is sent
+	done      chan struct{} // closed once the read loop ends
+}
+
+// newConn wraps ws. The connection's context keeps the request's values,
+// such as its ID, but not its deadline, and ends when the connection does.
+func newConn(h *Hub, ws *websocket.Conn, reqCtx context.Context) *Conn {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(reqCtx))
+	return &Conn{
+		hub:     h,
+		ws:      ws,
+		ctx:     ctx,
+		cancel:  cancel,
+		send:    make(chan Message, h.sendBuffer),
+		closing: make(chan closeRequest, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Context returns a context that is canceled when the connection closes
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Send queues msg for the client. It never blocks: if the client has
+// fallen a full send buffer behind, the connection is dropped instead.
+func (c *Conn) Send(msg Message) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		slog.WarnContext(c.ctx, "dropping slow websocket client", "remote_addr", c.ws.RemoteAddr().String())
+		_ = c.ws.Close()
+		return ErrSlowConsumer
+	}
+}
+
+// Close starts the close handshake with the given close code and reason,
+// e.g. CloseNormalClosure. It does not wait for the client;
+// connections that have not closed within the hub's close timeout are
+// dropped. Later calls have no effect.
+func (c *Conn) Close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closing <- closeRequest{code: code, text: text}
+	})
+}
+
+// serve runs the connection until it closes: writes and pings on a
+// separate goroutine, reads on this one
+func (c *Conn) serve(onMessage func(*Conn, Message)) {
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		c.writeLoop()
+	}()
+
+	c.readLoop(onMessage)
+	close(c.done)
+	c.cancel()
+
+	<-writerDone
+	_ = c.ws.Close()
+}
+
+func (c *Conn) readLoop(onMessage func(*Conn, Message)) {
+	pongWait := c.hub.pingInterval + c.hub.writeTimeout
+
+	c.ws.SetReadLimit(c.hub.maxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		// Once closing, the close deadline stands
+		if c.closed.Load() {
+			return nil
+		}
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		typ, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err,
+				websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				slog.DebugContext(c.ctx, "websocket closed", "error", err)
+			}
+			return
+		}
+		onMessage(c, Message{Type: typ, Data: data})
+	}
+}
+
+// writeLoop is the connection's only writer, as gorilla/websocket allows
+// one concurrent writer. It returns once the read loop ends.
+func (c *Conn) writeLoop() {
+	ticker := time.NewTicker(c.hub.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			// Nothing may follow the close frame
+			if c.closed.Load() {
+				continue
+			}
+			if err := c.write(msg.Type, msg.Data); err != nil {
+				_ = c.ws.Close()
+				return
+			}
+		case <-ticker.C:
+			if c.closed.Load() {
+				continue
+			}
+			if err := c.write(websocket.PingMessage, nil); err != nil {
+				_ = c.ws.Close()
+				return
+			}
+		case req := <-c.closing:
+			// The read loop ends when the client echoes the close frame,
+			// or at the deadline if it never does
+			c.closed.Store(true)
+			_ = c.write(websocket.CloseMessage, websocket.FormatCloseMessage(req.code, req.text))
+			_ = c.ws.SetReadDeadline(time.Now().Add(c.hub.closeTimeout))
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Conn) write(typ int, data []byte) error {
+	_ = c.ws.SetWriteDeadline(time.Now().Add(c.hub.writeTimeout))
+	return c.ws.WriteMessage(typ, data)
+}
@@ -0,0 +1,222 @@
+// pkg/ws/hub.go
+package ws
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/yourname/myapp/pkg/errors"
+	"github.com/yourname/myapp/pkg/response"
+)
+
+const (
+	defaultMaxMessageSize = 64 << 10
+	defaultPingInterval   = 30 * time.Second
+	defaultWriteTimeout   = 10 * time.Second
+	defaultCloseTimeout   = 5 * time.Second
+	defaultSendBuffer     = 32
+)
+
+// Option configures a Hub
+type Option func(*Hub)
+
+// WithMaxMessageSize sets the largest message accepted from clients, in
+// bytes. Larger messages close the connection.
+func WithMaxMessageSize(n int64) Option {
+	return func(h *Hub) {
+		h.maxMessageSize = n
+	}
+}
+
+// WithPingInterval sets how often clients are pinged. A client that does
+// not answer before the next ping is disconnected.
+func WithPingInterval(d time.Duration) Option {
+	return func(h *Hub) {
+		h.pingInterval = d
+	}
+}
+
+// WithWriteTimeout bounds how long a single write to a client may take
+func WithWriteTimeout(d time.Duration) Option {
+	return func(h *Hub) {
+		h.writeTimeout = d
+	}
+}
+
+// WithCloseTimeout bounds how long Close waits for clients to acknowledge
+// the close handshake before dropping their connections
+func WithCloseTimeout(d time.Duration) Option {
+	return func(h *Hub) {
+		h.closeTimeout = d
+	}
+}
+
+// WithSendBuffer sets how many outgoing messages may queue per client. A
+// client that falls further behind is disconnected.
+func WithSendBuffer(n int) Option {
+	return func(h *Hub) {
+		h.sendBuffer = n
+	}
+}
+
+// WithOriginAllowed accepts cross-origin upgrades whose Origin header
+// allowed approves. Same-origin and non-browser clients are always
+// accepted; without this option cross-origin upgrades are rejected.
+func WithOriginAllowed(allowed func(origin string) bool) Option {
+	return func(h *Hub) {
+		h.originAllowed = allowed
+	}
+}
+
+// Hub tracks open websocket connections so messages can be broadcast to
+// all of them and they can be closed together on shutdown
+type Hub struct {
+	upgrader       websocket.Upgrader
+	maxMessageSize int64
+	pingInterval   time.Duration
+	writeTimeout   time.Duration
+	closeTimeout   time.Duration
+	sendBuffer     int
+	originAllowed  func(origin string) bool
+
+	mu     sync.Mutex
+	conns  map[*Conn]struct{}
+	closed bool
+}
+
+// NewHub creates a Hub with options
+func NewHub(opts ...Option) *Hub {
+	h := &Hub{
+		maxMessageSize: defaultMaxMessageSize,
+		pingInterval:   defaultPingInterval,
+		writeTimeout:   defaultWriteTimeout,
+		closeTimeout:   defaultCloseTimeout,
+		sendBuffer:     defaultSendBuffer,
+		conns:          make(map[*Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.upgrader = websocket.Upgrader{
+		HandshakeTimeout: h.writeTimeout,
+		CheckOrigin:      h.checkOrigin,
+	}
+	return h
+}
+
+// Handler upgrades requests to websockets and calls onMessage with each
+// message received, in order, until the connection closes. The handler
+// runs for the lifetime of the connection, so the server counts it as in
+// flight until then.
+func (h *Hub) Handler(onMessage func(*Conn, Message)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !websocket.IsWebSocketUpgrade(c.Request) {
+			response.Error(c, errors.Validation("websocket upgrade required"))
+			return
+		}
+		if h.isClosed() {
+			response.Error(c, errors.ErrUnavailable)
+			return
+		}
+
+		// Keep headers set by middleware, such as X-Request-ID
+		wsConn, err := h.upgrader.Upgrade(c.Writer, c.Request, c.Writer.Header())
+		if err != nil {
+			return // the upgrader has already replied
+		}
+
+		conn := newConn(h, wsConn, c.Request.Context())
+		if !h.Register(conn) {
+			conn.Close(CloseGoingAway, "server shutting down")
+		}
+		defer h.Unregister(conn)
+
+		conn.serve(onMessage)
+	}
+}
+
+// Register adds conn to the hub, reporting false if the hub is closed
+func (h *Hub) Register(conn *Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.conns[conn] = struct{}{}
+	return true
+}
+
+// Unregister removes conn from the hub
+func (h *Hub) Unregister(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.conns, conn)
+}
+
+// Broadcast queues msg for every open connection
+func (h *Hub) Broadcast(msg Message) {
+	for _, conn := range h.snapshot() {
+		_ = conn.Send(msg)
+	}
+}
+
+// Len returns the number of open connections
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.conns)
+}
+
+// Close stops accepting connections and starts the close handshake on
+// every open one without waiting for it. Connections still open after the
+// close timeout are dropped, ending their handlers, so call Close as
+// shutdown begins to let them finish within the drain window.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, conn := range h.snapshot() {
+		conn.Close(CloseGoingAway, "server shutting down")
+	}
+}
+
+func (h *Hub) isClosed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.closed
+}
+
+func (h *Hub) snapshot() []*Conn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conns := make([]*Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// checkOrigin accepts requests without an Origin header (non-browser
+// clients), same-origin requests, and origins approved by originAllowed
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.originAllowed != nil && h.originAllowed(origin)
+}
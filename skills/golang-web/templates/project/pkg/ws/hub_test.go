@@ -0,0 +1,143 @@
+// pkg/ws/hub_test.go
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer serves an echo handler from hub, reporting on handlerDone
+// each time a connection's handler returns
+func newEchoServer(t *testing.T, hub *Hub) (url string, handlerDone *sync.WaitGroup) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handlerDone = new(sync.WaitGroup)
+	echo := hub.Handler(func(conn *Conn, msg Message) {
+		_ = conn.Send(msg)
+	})
+	r := gin.New()
+	r.GET("/ws", func(c *gin.Context) {
+		handlerDone.Add(1)
+		defer handlerDone.Done()
+		echo(c)
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws", handlerDone
+}
+
+func dial(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readText(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(data)
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEchoAndBroadcast(t *testing.T) {
+	hub := NewHub()
+	url, _ := newEchoServer(t, hub)
+
+	a := dial(t, url, nil)
+	b := dial(t, url, nil)
+	waitFor(t, "both clients to register", func() bool { return hub.Len() == 2 })
+
+	if err := a.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := readText(t, a); got != "hello" {
+		t.Errorf("echo = %q, want %q", got, "hello")
+	}
+
+	hub.Broadcast(Text("to all"))
+	for _, conn := range []*websocket.Conn{a, b} {
+		if got := readText(t, conn); got != "to all" {
+			t.Errorf("broadcast = %q, want %q", got, "to all")
+		}
+	}
+
+	a.Close()
+	waitFor(t, "the closed client to unregister", func() bool { return hub.Len() == 1 })
+}
+
+func TestCloseEndsConnections(t *testing.T) {
+	hub := NewHub(WithCloseTimeout(200 * time.Millisecond))
+	url, handlerDone := newEchoServer(t, hub)
+
+	conn := dial(t, url, nil)
+	waitFor(t, "the client to register", func() bool { return hub.Len() == 1 })
+
+	hub.Close()
+
+	// The client answers the close frame, ending the handler promptly
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("read error = %v, want close going away", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handlerDone.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler still running after close")
+	}
+
+	if _, _, err := websocket.DefaultDialer.Dial(url, nil); err == nil {
+		t.Error("dial after close succeeded, want it rejected")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	hub := NewHub(WithOriginAllowed(func(origin string) bool {
+		return origin == "https://app.example.com"
+	}))
+	url, _ := newEchoServer(t, hub)
+
+	dial(t, url, http.Header{"Origin": {"https://app.example.com"}})
+	if _, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example.com"}}); err == nil {
+		t.Error("dial from disallowed origin succeeded")
+	} else if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("dial from disallowed origin: %v, want 403", err)
+	}
+}